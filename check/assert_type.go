@@ -0,0 +1,342 @@
+package check
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	pgs "github.com/lyft/protoc-gen-star/v2"
+)
+
+// 将JSON中的值转换为字段类型，lenient为true时允许数值、布尔值以字符串形式给出
+type convertFunc[T any] func(v any, lenient bool) (T, error)
+
+var typeConvertFuncMap map[string]convertFunc[any] = make(map[string]convertFunc[any])
+
+func init() {
+	typeConvertFuncMap["uint32"] = toUint32
+	typeConvertFuncMap["fixed32"] = toUint32
+	typeConvertFuncMap["uint64"] = toUint64
+	typeConvertFuncMap["fixed64"] = toUint64
+	typeConvertFuncMap["int32"] = toInt32
+	typeConvertFuncMap["sint32"] = toInt32
+	typeConvertFuncMap["sfixed32"] = toInt32
+	typeConvertFuncMap["int64"] = toInt64
+	typeConvertFuncMap["sint64"] = toInt64
+	typeConvertFuncMap["sfixed64"] = toInt64
+	typeConvertFuncMap["double"] = toFloat64
+	typeConvertFuncMap["float"] = toFloat32
+	typeConvertFuncMap["bool"] = toBool
+	typeConvertFuncMap["string"] = toString
+	typeConvertFuncMap["timestamp"] = toTimestamp
+	typeConvertFuncMap["duration"] = toDuration
+	typeConvertFuncMap["any"] = toAnyTypeURL
+}
+
+// JSON值的类型名，用于类型不匹配的提示
+func jsonTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case bool:
+		return "bool"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func typeMismatch(expected string, v any) error {
+	return fmt.Errorf("类型不匹配: expected %s, got %s", expected, jsonTypeName(v))
+}
+
+// 获取数值的文本形式。
+// 64位整数和特殊浮点数按照protojson的约定允许使用字符串，其他类型仅在宽松模式下允许
+func numberText(v any, typ string, quoted bool) (string, error) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.String(), nil
+	case float64:
+		return strconv.FormatFloat(n, 'g', -1, 64), nil
+	case string:
+		if quoted {
+			return n, nil
+		}
+	}
+	return "", typeMismatch(typ, v)
+}
+
+// Convert to int32
+func toInt32(v any, lenient bool) (any, error) {
+	s, err := numberText(v, "int32", lenient)
+	if err != nil {
+		return int32(0), err
+	}
+	i, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return int32(0), err
+	}
+	return int32(i), nil
+}
+
+// Convert to uint32
+func toUint32(v any, lenient bool) (any, error) {
+	s, err := numberText(v, "uint32", lenient)
+	if err != nil {
+		return uint32(0), err
+	}
+	i, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return uint32(0), err
+	}
+	return uint32(i), nil
+}
+
+// Convert to int64, protojson中64位整数通常以字符串表示
+func toInt64(v any, lenient bool) (any, error) {
+	s, err := numberText(v, "int64", true)
+	if err != nil {
+		return int64(0), err
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// Convert to uint64, protojson中64位整数通常以字符串表示
+func toUint64(v any, lenient bool) (any, error) {
+	s, err := numberText(v, "uint64", true)
+	if err != nil {
+		return uint64(0), err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// 特殊浮点数NaN、Infinity、-Infinity在protojson中以字符串表示
+func isSpecialFloat(v any) bool {
+	s, ok := v.(string)
+	return ok && (s == "NaN" || s == "Infinity" || s == "-Infinity")
+}
+
+func parseFloat(v any, typ string, bitSize int, lenient bool) (float64, error) {
+	if isSpecialFloat(v) {
+		switch v.(string) {
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		default:
+			return math.NaN(), nil
+		}
+	}
+	s, err := numberText(v, typ, lenient)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(s, bitSize)
+}
+
+// Convert to float32
+func toFloat32(v any, lenient bool) (any, error) {
+	f, err := parseFloat(v, "float", 32, lenient)
+	if err != nil {
+		return float32(0), err
+	}
+	return float32(f), nil
+}
+
+// Convert to float64
+func toFloat64(v any, lenient bool) (any, error) {
+	return parseFloat(v, "double", 64, lenient)
+}
+
+func toBool(v any, lenient bool) (any, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		if lenient {
+			return strconv.ParseBool(b)
+		}
+	}
+	return false, typeMismatch("bool", v)
+}
+
+// 字符串不是合法的UTF-8编码，报告为 string.utf8
+var errInvalidUTF8 = errors.New("不是合法的UTF-8编码")
+
+// 字符串必须是合法的UTF-8编码
+func toString(v any, lenient bool) (any, error) {
+	if s, ok := v.(string); ok {
+		if !utf8.ValidString(s) {
+			return "", fmt.Errorf("字符串 %q %w", s, errInvalidUTF8)
+		}
+		return s, nil
+	}
+	return "", typeMismatch("string", v)
+}
+
+// JSON中的字节串默认按照protojson的约定使用base64编码，encoding可以指定为hex或raw（原始字符串）。
+// 二进制和文本格式解码得到的字节串直接使用
+func toBytes(v any, encoding string) (any, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case string:
+		switch encoding {
+		case BytesRaw:
+			return []byte(b), nil
+		case BytesHex:
+			return hex.DecodeString(b)
+		default:
+			return decodeBase64(b)
+		}
+	}
+	return []byte(nil), typeMismatch("bytes", v)
+}
+
+// 与protojson一致，同时支持标准和URL安全的base64，填充可省略
+func decodeBase64(s string) ([]byte, error) {
+	enc := base64.StdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = base64.URLEncoding
+	}
+	if len(s)%4 != 0 {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+	b, err := enc.DecodeString(s)
+	if err != nil {
+		return []byte(nil), fmt.Errorf("字节串不是合法的base64编码: %w", err)
+	}
+	return b, nil
+}
+
+// google.protobuf.Timestamp 的值，按照RFC 3339格式输出
+type Timestamp struct {
+	time.Time
+}
+
+func (t Timestamp) String() string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// 与protojson一致，Timestamp使用RFC 3339格式的字符串，如 "2024-01-02T15:04:05.5Z"
+func toTimestamp(v any, lenient bool) (any, error) {
+	s, ok := v.(string)
+	if !ok {
+		return Timestamp{}, typeMismatch("timestamp string", v)
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("时间 %q 不是合法的RFC 3339格式", s)
+	}
+	return Timestamp{t}, nil
+}
+
+// google.protobuf.Duration 的值。与time.Duration不同，可以表示Duration的完整范围 ±315,576,000,000s
+type Duration struct {
+	Seconds int64
+	Nanos   int32 // 与Seconds的符号相同
+}
+
+// 按照protojson的格式输出，如 1.5s
+func (d Duration) String() string {
+	return formatDuration(d.Seconds, int64(d.Nanos))
+}
+
+func durationLess(a, b Duration) bool {
+	return a.Seconds < b.Seconds || a.Seconds == b.Seconds && a.Nanos < b.Nanos
+}
+
+// Duration的秒数范围，约10000年
+const maxDurationSeconds = 315576000000
+
+var durationPattern = regexp.MustCompile(`^(-?)([0-9]+)(?:\.([0-9]{1,9}))?s$`)
+
+// 与protojson一致，Duration使用以s结尾的秒数，如 "1.5s"
+func toDuration(v any, lenient bool) (any, error) {
+	s, ok := v.(string)
+	if !ok {
+		return Duration{}, typeMismatch("duration string", v)
+	}
+	match := durationPattern.FindStringSubmatch(s)
+	if match == nil {
+		return Duration{}, fmt.Errorf("时长 %q 格式不正确，应为以s结尾的秒数，如 \"1.5s\"", s)
+	}
+	seconds, err := strconv.ParseInt(match[2], 10, 64)
+	if err != nil || seconds > maxDurationSeconds {
+		return Duration{}, fmt.Errorf("时长 %q 超出范围", s)
+	}
+	nanos, _ := strconv.ParseInt((match[3] + "000000000")[:9], 10, 32)
+	if match[1] == "-" {
+		seconds, nanos = -seconds, -nanos
+	}
+	return Duration{Seconds: seconds, Nanos: int32(nanos)}, nil
+}
+
+// 与protojson一致，Any是带有 "@type" 的JSON对象，返回其中的type_url
+func toAnyTypeURL(v any, lenient bool) (any, error) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return "", typeMismatch("object", v)
+	}
+	type_url, ok := obj["@type"].(string)
+	if !ok {
+		return "", fmt.Errorf("Any 缺少字符串类型的 @type")
+	}
+	return type_url, nil
+}
+
+// 枚举值可以使用名称或数值，format 限制只能使用其中一种写法
+func toEnum(v any, enum pgs.Enum, format string, lenient bool) (any, error) {
+	if name, ok := v.(string); ok {
+		for _, ev := range enum.Values() {
+			if ev.Name().String() != name {
+				continue
+			}
+			if format == EnumNumber {
+				return int32(0), fmt.Errorf("枚举 %s 只允许使用数值: %s", enum.Name(), name)
+			}
+			return ev.Value(), nil
+		}
+		// 宽松模式下允许字符串形式的数值
+		if _, err := strconv.ParseInt(name, 10, 32); !lenient || err != nil {
+			return int32(0), fmt.Errorf("枚举 %s 没有名为 %s 的值，可选: %s", enum.Name(), name, enumValueNames(enum))
+		}
+	}
+
+	if format == EnumName {
+		return int32(0), fmt.Errorf("枚举 %s 只允许使用名称，可选: %s", enum.Name(), enumValueNames(enum))
+	}
+	s, err := numberText(v, "enum", lenient)
+	if err != nil {
+		return int32(0), err
+	}
+	i, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return int32(0), err
+	}
+	return int32(i), nil
+}
+
+// MESSAGE(1), FACE(2)
+func enumValueNames(enum pgs.Enum) string {
+	names := make([]string, 0, len(enum.Values()))
+	for _, ev := range enum.Values() {
+		names = append(names, fmt.Sprintf("%s(%d)", ev.Name(), ev.Value()))
+	}
+	return strings.Join(names, ", ")
+}
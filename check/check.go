@@ -0,0 +1,181 @@
+// Package check 按照proto文件中的PGV规则（validate.rules）校验JSON、二进制或文本格式的数据。
+//
+// 用法：
+//
+//	descs, err := check.LoadDescriptors(req) // req 为 CodeGeneratorRequest，如 protoc-gen-debug 的输出
+//	validator, err := descs.Compile("example.Protocol", &check.Options{})
+//	data, result, err := validator.Validate(payload) // result 为结构化的校验错误，没有错误时为空
+package check
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+
+	pgs "github.com/lyft/protoc-gen-star/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// 一组proto文件的描述符
+type Descriptors struct {
+	files   *protoregistry.Files // 用于查找消息的描述符，解码二进制和文本格式的数据
+	ast     pgs.AST              // 用于读取字段的校验规则
+	targets []string             // 请求中需要生成的文件，未指定消息时从这些文件中选择
+
+	mu    sync.Mutex
+	plans map[string]*messagePlan // 已编译的消息，多次编译时复用
+}
+
+// 从CodeGeneratorRequest中加载所有proto文件的描述符
+func LoadDescriptors(req []byte) (*Descriptors, error) {
+	var request pluginpb.CodeGeneratorRequest
+	if err := proto.Unmarshal(req, &request); err != nil {
+		return nil, err
+	}
+	return NewDescriptors(&request)
+}
+
+// 从已解析的CodeGeneratorRequest中加载描述符，FileDescriptorSet 可以包装为只有 ProtoFile 的请求
+func NewDescriptors(req *pluginpb.CodeGeneratorRequest) (*Descriptors, error) {
+	// 先检查依赖是否完整，pgs在依赖缺失时会直接退出
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: req.GetProtoFile()})
+	if err != nil {
+		return nil, err
+	}
+
+	debugger := pgs.InitMockDebugger()
+	ast := pgs.ProcessCodeGeneratorRequest(debugger, req)
+	if debugger.Failed() {
+		return nil, fmt.Errorf("解析proto文件失败: %v", debugger.Err())
+	}
+	return &Descriptors{files: files, ast: ast, targets: req.GetFileToGenerate(), plans: make(map[string]*messagePlan)}, nil
+}
+
+/*
+*
+
+	编译消息的校验器
+	1. name 为消息的全限定名，如 example.Protocol
+	2. name 为空且需要生成的文件中只有一个消息时，使用该消息
+	3. 编译结果（包括嵌套的消息）会被缓存，再次编译同一个消息时直接复用
+	4. 规则有误时返回错误，如正则表达式无法编译、规则类型与字段类型不一致
+*/
+func (d *Descriptors) Compile(name string, opts *Options) (*Validator, error) {
+	m, err := d.message(name)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return newValidator(m, d.files, opts, d.plans)
+}
+
+func (d *Descriptors) message(name string) (pgs.Message, error) {
+	if name == "" {
+		var candidates []pgs.Message
+		for _, target := range d.targets {
+			if f, ok := d.ast.Lookup(target); ok {
+				candidates = append(candidates, f.(pgs.File).AllMessages()...)
+			}
+		}
+		if len(candidates) == 1 {
+			return candidates[0], nil
+		}
+		return nil, fmt.Errorf("请通过 --message 指定要校验的消息，可选: %s", messageNames(candidates))
+	}
+
+	if e, ok := d.ast.Lookup("." + strings.TrimPrefix(name, ".")); ok {
+		if m, ok := e.(pgs.Message); ok {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("未找到消息 %s", name)
+}
+
+func messageNames(msgs []pgs.Message) string {
+	names := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		names = append(names, strings.TrimPrefix(m.FullyQualifiedName(), "."))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// 一个消息的校验器，持有编译好的校验计划，可以在多个goroutine中同时使用
+type Validator struct {
+	plan *messagePlan
+	desc protoreflect.MessageDescriptor
+	opts Options
+}
+
+// 编译消息的校验器，plans为已编译的消息
+func newValidator(m pgs.Message, files *protoregistry.Files, opts *Options, plans map[string]*messagePlan) (*Validator, error) {
+	name := protoreflect.FullName(strings.TrimPrefix(m.FullyQualifiedName(), "."))
+	desc, err := files.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s 不是消息类型", name)
+	}
+
+	// 编译失败时不保留编译了一半的计划，成功后再合并到缓存
+	plan, ok := plans[m.FullyQualifiedName()]
+	if !ok {
+		compiled := maps.Clone(plans)
+		if plan, err = compileMessage(m, md, compiled); err != nil {
+			return nil, err
+		}
+		maps.Copy(plans, compiled)
+	}
+
+	v := &Validator{plan: plan, desc: md}
+	if opts != nil {
+		v.opts = *opts
+	}
+	return v, nil
+}
+
+// 根消息的全限定名，如 example.Protocol
+func (v *Validator) Message() string {
+	return string(v.desc.FullName())
+}
+
+// 解码并校验数据，返回解码后的数据（包括写入的默认值）和校验结果。
+// 数据无法解码时返回错误
+func (v *Validator) Validate(p *Payload) (map[string]any, ValidationResult, error) {
+	data, msg, err := p.decode(v.desc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 二进制和文本格式解码后枚举均为数值、字节串为原始内容，不限制写法和编码
+	opts := v.opts
+	if p.Format != PayloadJSON {
+		opts.EnumFormat, opts.BytesEncoding = "", ""
+	}
+	result := v.plan.check(data, "", &opts)
+
+	// 二进制数据中的未知字段不在解码后的数据中，严格模式下单独报告
+	if opts.Strict && msg != nil {
+		result = append(result, checkUnknownWire(msg, "")...)
+	}
+	return data, result, nil
+}
+
+// 校验已解析为JSON对象的数据，数值应为json.Number。data为nil时视为空对象
+func (v *Validator) ValidateData(data map[string]any) ValidationResult {
+	if data == nil {
+		data = make(map[string]any)
+	}
+	return v.plan.check(data, "", &v.opts)
+}
@@ -0,0 +1,1000 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/envoyproxy/protoc-gen-validate/validate"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func loadValidator(t testing.TB, name string, message string, opts *Options) *Validator {
+	t.Helper()
+	req, err := os.ReadFile("../testdata/pb_bin/" + name + ".pb.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	descs, err := LoadDescriptors(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := descs.Compile(message, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func loadPayload(t testing.TB, name string) *Payload {
+	t.Helper()
+	p, err := ReadPayload("../testdata/payload/"+name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+type concurrentCase struct {
+	name      string
+	validator *Validator
+	payload   *Payload
+	rules     []string // 期望的错误的规则id
+}
+
+// 多个goroutine同时校验不同的消息和枚举，结果和调试信息互不影响。需要配合 -race 运行
+func TestValidateConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	var traces []*FieldTrace
+	opts := &Options{Trace: func(tr *FieldTrace) {
+		mu.Lock()
+		defer mu.Unlock()
+		traces = append(traces, tr)
+	}}
+
+	simple := loadValidator(t, "simple", "example.Protocol", opts)
+	tango := loadValidator(t, "tango_verify_result_verify", "example.Protocol", opts)
+	valid := loadPayload(t, "simple.json")
+	undefined := &Payload{Path: "undefined.json", Format: PayloadJSON, Raw: bytes.Replace(valid.Raw, []byte(`"FACE"`), []byte(`4`), 1)}
+
+	cases := []concurrentCase{
+		{"simple", simple, valid, nil},
+		{"undefined enum", simple, undefined, []string{"enum.defined_only"}},
+		{"tango json", tango, loadPayload(t, "tango_verify_result_verify.json"), nil},
+		{"tango binary", tango, loadPayload(t, "tango_verify_result_verify.bin"), nil},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, c := range cases {
+			wg.Add(1)
+			go func(c concurrentCase) {
+				defer wg.Done()
+				_, result, err := c.validator.Validate(c.payload)
+				if err != nil {
+					t.Errorf("%s: %v", c.name, err)
+					return
+				}
+				if len(result) != len(c.rules) {
+					t.Errorf("%s: got %d violations %v, want %v", c.name, len(result), result, c.rules)
+					return
+				}
+				for j, v := range result {
+					if v.Rule != c.rules[j] {
+						t.Errorf("%s: got rule %s, want %s", c.name, v.Rule, c.rules[j])
+					}
+				}
+			}(c)
+		}
+	}
+	wg.Wait()
+
+	// 每条调试信息只包含所属字段的值和规则
+	checked := 0
+	for _, tr := range traces {
+		if tr.Path != "verify_types" {
+			continue
+		}
+		checked++
+		if tr.Value != "[FACE 1]" && tr.Value != "[4 1]" {
+			t.Errorf("verify_types: unexpected value %q", tr.Value)
+		}
+		if len(tr.Rules) != 1 || tr.Rules["defined_only"] != true {
+			t.Errorf("verify_types: unexpected rules %v", tr.Rules)
+		}
+	}
+	if checked != 2*20 {
+		t.Errorf("got %d traces of verify_types, want %d", checked, 2*20)
+	}
+}
+
+// tango_verify_result_verify 中 data.face_info 的数据，共65个字段
+func faceInfoPayload(b *testing.B) *Payload {
+	b.Helper()
+	var protocol struct {
+		Data struct {
+			FaceInfo json.RawMessage `json:"face_info"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(loadPayload(b, "tango_verify_result_verify.json").Raw, &protocol); err != nil {
+		b.Fatal(err)
+	}
+	return &Payload{Path: "face_info.json", Format: PayloadJSON, Raw: protocol.Data.FaceInfo}
+}
+
+// 编译FaceInfo的校验器，每次使用新的缓存，不复用已编译的计划
+func BenchmarkCompileFaceInfo(b *testing.B) {
+	req, err := os.ReadFile("../testdata/pb_bin/tango_verify_result_verify.pb.bin")
+	if err != nil {
+		b.Fatal(err)
+	}
+	descs, err := LoadDescriptors(req)
+	if err != nil {
+		b.Fatal(err)
+	}
+	m, err := descs.message("example.FaceInfo")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := newValidator(m, descs.files, &Options{}, make(map[string]*messagePlan)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 解码并校验FaceInfo的JSON数据
+func BenchmarkValidateFaceInfo(b *testing.B) {
+	v := loadValidator(b, "tango_verify_result_verify", "example.FaceInfo", &Options{})
+	payload := faceInfoPayload(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, result, err := v.Validate(payload); err != nil || !result.Valid() {
+			b.Fatal(err, result)
+		}
+	}
+}
+
+// 只校验已解码的FaceInfo数据，不包括JSON解码
+func BenchmarkValidateDataFaceInfo(b *testing.B) {
+	v := loadValidator(b, "tango_verify_result_verify", "example.FaceInfo", &Options{})
+	data, err := faceInfoPayload(b).Decode(v.desc)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if result := v.ValidateData(data); !result.Valid() {
+			b.Fatal(result)
+		}
+	}
+}
+
+// 校验数据，返回错误的路径和规则id
+func violations(t *testing.T, name string, message string, opts *Options, payload *Payload) []string {
+	t.Helper()
+	_, result, err := loadValidator(t, name, message, opts).Validate(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range result {
+		got = append(got, v.Path+" "+v.Rule)
+	}
+	return got
+}
+
+// 校验simple.json替换部分内容后的数据
+func checkSimple(t *testing.T, opts *Options, old string, new string) []string {
+	t.Helper()
+	payload := loadPayload(t, "simple.json")
+	if !bytes.Contains(payload.Raw, []byte(old)) {
+		t.Fatalf("simple.json 中没有 %s", old)
+	}
+	payload.Raw = bytes.Replace(payload.Raw, []byte(old), []byte(new), 1)
+	return violations(t, "simple", "example.Protocol", opts, payload)
+}
+
+type simpleCase struct {
+	name string
+	opts Options
+	old  string
+	new  string
+	want []string
+}
+
+func runSimple(t *testing.T, cases []simpleCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := checkSimple(t, &c.opts, c.old, c.new)
+			if strings.Join(got, "; ") != strings.Join(c.want, "; ") {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+type jsonCase struct {
+	name string
+	opts Options
+	raw  string
+	want []string
+}
+
+// 用描述符文件name中的消息message校验JSON数据
+func runJSON(t *testing.T, name string, message string, cases []jsonCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			payload := &Payload{Path: name + ".json", Format: PayloadJSON, Raw: []byte(c.raw)}
+			got := violations(t, name, message, &c.opts, payload)
+			if strings.Join(got, "; ") != strings.Join(c.want, "; ") {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+// 字符串必须是合法的UTF-8
+func TestValidateSimple(t *testing.T) {
+	runSimple(t, []simpleCase{
+		{"valid", Options{}, `"ab"`, `"ab"`, nil},
+		{"invalid utf8", Options{}, `"aaaaaaaaaaaa"`, "\"aaaa\xffaaaaaaa\"", []string{"string_val string.utf8"}},
+		{"lone surrogate", Options{}, `"cd"`, `"c\ud800d"`, []string{"tags[1] string.utf8"}},
+		{"surrogate pair", Options{}, `"prod"`, `"\ud83d\ude00"`, nil},
+		{"lone surrogate key", Options{}, `"env"`, `"\udc00x"`, []string{`labels["\xed\xb0\x80x"] string.utf8`}},
+	})
+}
+
+// repeated的错误指向具体的元素
+func TestValidateRepeated(t *testing.T) {
+	runSimple(t, []simpleCase{
+		{"item", Options{}, `"cd"`, `"cd", "e"`, []string{"tags[2] string.min_len"}},
+		{"max items", Options{}, `"cd"`, `"cd", "ef", "gh"`, []string{"tags repeated.max_items"}},
+		{"unique", Options{}, `"cd"`, `"ab"`, []string{"tags repeated.unique"}},
+		{"item type", Options{}, `"cd"`, `3`, []string{"tags[1] type"}},
+	})
+}
+
+// map的错误指向具体的键或值
+func TestValidateMap(t *testing.T) {
+	runSimple(t, []simpleCase{
+		{"value", Options{}, `"prod"`, `"production"`, []string{`labels["env"] string.max_len`}},
+		{"key", Options{}, `"env"`, `"e"`, []string{`labels["e"] string.min_len`}},
+		{"max pairs", Options{}, `"env": "prod"`, `"env": "prod", "os": "mac", "db": "pg"`, []string{"labels map.max_pairs"}},
+	})
+}
+
+// JSON中字节串的编码
+func TestValidateBytes(t *testing.T) {
+	runSimple(t, []simpleCase{
+		{"base64url", Options{}, `"YWFhYWFhYWFhYQ=="`, `"YWFhYWFhYWFhYQ"`, nil},
+		{"base64 invalid", Options{}, `"YWFhYWFhYWFhYQ=="`, `"!!"`, []string{"bytes_val type"}},
+		{"hex", Options{BytesEncoding: BytesHex}, `"YWFhYWFhYWFhYQ=="`, `"61616161616161616161"`, nil},
+		{"hex invalid", Options{BytesEncoding: BytesHex}, `"YWFhYWFhYWFhYQ=="`, `"6161zz"`, []string{"bytes_val type"}},
+		{"raw", Options{BytesEncoding: BytesRaw}, `"YWFhYWFhYWFhYQ=="`, `"aaaaaaaaaa"`, nil},
+		{"raw rules", Options{BytesEncoding: BytesRaw}, `"YWFhYWFhYWFhYQ=="`, `"baaaaaaaaa"`, []string{"bytes_val bytes.pattern", "bytes_val bytes.prefix"}},
+	})
+}
+
+// 枚举值可以是名称或数值，EnumFormat限制只能使用其中一种
+func TestValidateEnum(t *testing.T) {
+	runSimple(t, []simpleCase{
+		{"name", Options{}, `"MESSAGE"`, `"FACE"`, []string{"verify_type enum.const"}},
+		{"number", Options{}, `"MESSAGE"`, `1`, nil},
+		{"unknown name", Options{}, `"MESSAGE"`, `"SMS"`, []string{"verify_type type"}},
+		{"undefined number", Options{}, `"FACE"`, `"FACE", 9`, []string{"verify_types[1] enum.defined_only"}},
+		{"quoted number", Options{}, `"MESSAGE"`, `"1"`, []string{"verify_type type"}},
+		{"quoted number lenient", Options{Lenient: true}, `"MESSAGE"`, `"1"`, nil},
+		{"name only", Options{EnumFormat: EnumName}, `"MESSAGE"`, `"MESSAGE"`, []string{"verify_types[1] type"}},
+		{"name only number", Options{EnumFormat: EnumName}, `"MESSAGE"`, `1`, []string{"verify_type type", "verify_types[1] type"}},
+		{"number only", Options{EnumFormat: EnumNumber}, `"MESSAGE"`, `1`, []string{"verify_types[0] type"}},
+		{"number only name", Options{EnumFormat: EnumNumber}, `"MESSAGE"`, `"MESSAGE"`, []string{"verify_type type", "verify_types[0] type"}},
+	})
+}
+
+func TestEnumMessage(t *testing.T) {
+	cases := []struct {
+		format string
+		val    string
+		want   string
+	}{
+		{"", `"SMS"`, "枚举 VertifyType 没有名为 SMS 的值，可选: MESSAGE(1), FACE(2), HAND(3)"},
+		{EnumName, `1`, "枚举 VertifyType 只允许使用名称，可选: MESSAGE(1), FACE(2), HAND(3)"},
+		{EnumNumber, `"FACE"`, "枚举 VertifyType 只允许使用数值: FACE"},
+	}
+	for _, c := range cases {
+		v := loadValidator(t, "simple", "example.Protocol", &Options{EnumFormat: c.format})
+		payload := loadPayload(t, "simple.json")
+		payload.Raw = bytes.Replace(payload.Raw, []byte(`"MESSAGE"`), []byte(c.val), 1)
+		_, result, err := v.Validate(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		for _, violation := range result {
+			if violation.Path == "verify_type" {
+				got = violation.Message
+			}
+		}
+		if got != c.want {
+			t.Errorf("format %q: got %q, want %q", c.format, got, c.want)
+		}
+	}
+}
+
+// 键可以是proto字段名或json_name，同一个字段的多种写法不能同时出现
+func TestValidateNames(t *testing.T) {
+	runJSON(t, "names", "example.Names", []jsonCase{
+		{"proto name", Options{}, `{"user_id": "ab", "display_name": "bob"}`, nil},
+		{"json name", Options{}, `{"userId": "a", "nick": "alice"}`, []string{"user_id string.min_len", "display_name string.max_len"}},
+		{"custom json name replaces default", Options{Strict: true}, `{"user_id": "ab", "displayName": "bob"}`, []string{"displayName unknown_field"}},
+		{"duplicate", Options{}, `{"user_id": "ab", "userId": "cd"}`, []string{"user_id duplicate"}},
+		{"duplicate null", Options{}, `{"display_name": null, "nick": "bob", "user_id": "ab"}`, []string{"display_name duplicate"}},
+	})
+}
+
+// 用规则校验单个值，返回错误的规则id
+func checkValue(t *testing.T, typ string, rules proto.Message, val any) []string {
+	t.Helper()
+	p, err := compileValue(typ, nil, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range p.check(val, &Options{}, newFieldTrace("", "")) {
+		got = append(got, v.Rule)
+	}
+	return got
+}
+
+// well_known中的格式规则，well_known_regex的strict默认为true
+func TestStringWellKnown(t *testing.T) {
+	header := func(known validate.KnownRegex, strict *bool) *validate.StringRules {
+		return &validate.StringRules{WellKnown: &validate.StringRules_WellKnownRegex{WellKnownRegex: known}, Strict: strict}
+	}
+	cases := []struct {
+		rule  string
+		rules *validate.StringRules
+		pass  []string
+		fail  []string
+	}{
+		{"string.email", &validate.StringRules{WellKnown: &validate.StringRules_Email{Email: true}},
+			[]string{"a@example.com", "Bob <bob@example.com>"},
+			[]string{"a", "a@", "a@-example.com", strings.Repeat("a", 65) + "@example.com"}},
+		{"string.hostname", &validate.StringRules{WellKnown: &validate.StringRules_Hostname{Hostname: true}},
+			[]string{"example.com", "a-b.Example.com.", "localhost"},
+			[]string{"-a.com", "a-.com", "a..com", "a_b.com", strings.Repeat("a", 64) + ".com"}},
+		{"string.ip", &validate.StringRules{WellKnown: &validate.StringRules_Ip{Ip: true}},
+			[]string{"127.0.0.1", "::1"},
+			[]string{"127.0.0", "example.com"}},
+		{"string.ipv4", &validate.StringRules{WellKnown: &validate.StringRules_Ipv4{Ipv4: true}},
+			[]string{"10.0.0.1"},
+			[]string{"::1", "256.0.0.1"}},
+		{"string.ipv6", &validate.StringRules{WellKnown: &validate.StringRules_Ipv6{Ipv6: true}},
+			[]string{"::1", "fe80::1"},
+			[]string{"10.0.0.1", "::g"}},
+		{"string.address", &validate.StringRules{WellKnown: &validate.StringRules_Address{Address: true}},
+			[]string{"example.com", "10.0.0.1", "::1"},
+			[]string{"a_b", "-a", "10.0.0.1:80"}},
+		{"string.uri", &validate.StringRules{WellKnown: &validate.StringRules_Uri{Uri: true}},
+			[]string{"https://example.com/a?b=c", "mailto:a@example.com"},
+			[]string{"/a/b", "example.com", "http://a b.com"}},
+		{"string.uri_ref", &validate.StringRules{WellKnown: &validate.StringRules_UriRef{UriRef: true}},
+			[]string{"/a/b", "../c", "https://example.com"},
+			[]string{"%zz", "http://a b.com"}},
+		{"string.uuid", &validate.StringRules{WellKnown: &validate.StringRules_Uuid{Uuid: true}},
+			[]string{"123e4567-e89b-12d3-a456-426614174000", "123E4567-E89B-12D3-A456-426614174000"},
+			[]string{"123e4567e89b12d3a456426614174000", "123e4567-e89b-12d3-a456-42661417400g"}},
+		{"string.well_known_regex", header(validate.KnownRegex_HTTP_HEADER_NAME, nil),
+			[]string{"Content-Type", ":authority"},
+			[]string{"Content Type", "a\r\nb", ""}},
+		{"string.well_known_regex", header(validate.KnownRegex_HTTP_HEADER_NAME, proto.Bool(false)),
+			[]string{"Content Type", ""},
+			[]string{"a\r\nb", "a\x00b"}},
+		{"string.well_known_regex", header(validate.KnownRegex_HTTP_HEADER_VALUE, proto.Bool(true)),
+			[]string{"text/html; charset=utf-8", "a\tb"},
+			[]string{"a\x01b", "a\nb", "a\x7fb"}},
+		{"string.well_known_regex", header(validate.KnownRegex_HTTP_HEADER_VALUE, proto.Bool(false)),
+			[]string{"a\x01b", "a\x7fb"},
+			[]string{"a\nb", "a\rb", "a\x00b"}},
+	}
+	for _, c := range cases {
+		for _, val := range c.pass {
+			if got := checkValue(t, "string", c.rules, val); got != nil {
+				t.Errorf("%s: %q got %v, want valid", c.rule, val, got)
+			}
+		}
+		for _, val := range c.fail {
+			if got := checkValue(t, "string", c.rules, val); len(got) != 1 || got[0] != c.rule {
+				t.Errorf("%s: %q got %v, want %s", c.rule, val, got, c.rule)
+			}
+		}
+	}
+}
+
+// len、min_len、max_len按字符计算，len_bytes、min_bytes、max_bytes按字节计算。"深圳市" 为3个字符、9个字节
+func TestStringLength(t *testing.T) {
+	cases := []struct {
+		name  string
+		rules *validate.StringRules
+		val   string
+		want  []string
+	}{
+		{"len", &validate.StringRules{Len: proto.Uint64(3)}, "深圳市", nil},
+		{"len short", &validate.StringRules{Len: proto.Uint64(3)}, "深圳", []string{"string.len"}},
+		{"min_len", &validate.StringRules{MinLen: proto.Uint64(3)}, "深圳市", nil},
+		{"min_len short", &validate.StringRules{MinLen: proto.Uint64(4)}, "深圳市", []string{"string.min_len"}},
+		{"max_len", &validate.StringRules{MaxLen: proto.Uint64(3)}, "深圳市", nil},
+		{"max_len long", &validate.StringRules{MaxLen: proto.Uint64(3)}, "深圳市区", []string{"string.max_len"}},
+		{"len_bytes", &validate.StringRules{LenBytes: proto.Uint64(9)}, "深圳市", nil},
+		{"len_bytes chars", &validate.StringRules{LenBytes: proto.Uint64(3)}, "深圳市", []string{"string.len_bytes"}},
+		{"min_bytes", &validate.StringRules{MinBytes: proto.Uint64(7)}, "深圳市", nil},
+		{"min_bytes ascii", &validate.StringRules{MinBytes: proto.Uint64(7)}, "abcdef", []string{"string.min_bytes"}},
+		{"max_bytes", &validate.StringRules{MaxBytes: proto.Uint64(6)}, "深圳", nil},
+		{"max_bytes long", &validate.StringRules{MaxBytes: proto.Uint64(6)}, "深圳市", []string{"string.max_bytes"}},
+		{"chars and bytes", &validate.StringRules{MaxLen: proto.Uint64(3), MaxBytes: proto.Uint64(6)}, "深圳市", []string{"string.max_bytes"}},
+	}
+	for _, c := range cases {
+		if got := checkValue(t, "string", c.rules, c.val); strings.Join(got, "; ") != strings.Join(c.want, "; ") {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+// 消息的disabled、ignored选项跳过整个消息，message.skip跳过嵌套消息，message.required要求必须设置
+func TestValidateMessageOptions(t *testing.T) {
+	runJSON(t, "message_options", "example.Container", []jsonCase{
+		{"valid", Options{}, `{"required": {"name": "ab"}}`, nil},
+		{"required", Options{}, `{}`, []string{"required message.required"}},
+		{"required null", Options{}, `{"required": null}`, []string{"required message.required"}},
+		{"required checked", Options{}, `{"required": {"name": "a"}}`, []string{"required.name string.min_len"}},
+		{"checked", Options{}, `{"required": {"name": "ab"}, "checked": {"name": "a"}}`, []string{"checked.name string.min_len"}},
+		{"disabled", Options{Strict: true}, `{"required": {"name": "ab"}, "disabled": {"name": "a", "other": 1}}`, nil},
+		{"ignored", Options{Strict: true}, `{"required": {"name": "ab"}, "ignored": {"name": "a", "other": 1}}`, nil},
+		{"skip", Options{}, `{"required": {"name": "ab"}, "skipped": {"name": "a"}}`, nil},
+		{"skip type", Options{}, `{"required": {"name": "ab"}, "skipped": "a"}`, []string{"skipped type"}},
+		{"skip items", Options{}, `{"required": {"name": "ab"}, "items": [{"name": "a"}]}`, nil},
+		{"skip values", Options{}, `{"required": {"name": "ab"}, "pairs": {"k": {"name": "a"}}}`, nil},
+	})
+	runJSON(t, "message_options", "example.Disabled", []jsonCase{
+		{"disabled root", Options{}, `{"name": "a"}`, nil},
+	})
+}
+
+// editions中的存在性由文件和字段的features.field_presence决定
+func TestValidateEditionsPresence(t *testing.T) {
+	runJSON(t, "editions", "example.Editions", []jsonCase{
+		{"valid", Options{}, `{"id": "a", "count": 1}`, nil},
+		{"implicit from file", Options{}, `{"id": "a"}`, []string{"count int32.gt"}},
+		{"explicit unset", Options{}, `{"id": "a", "count": 1, "nickname": null}`, nil},
+		{"explicit set", Options{}, `{"id": "a", "count": 1, "nickname": ""}`, []string{"nickname string.min_len"}},
+		{"legacy required", Options{}, `{"count": 1}`, []string{"id required"}},
+		{"message explicit", Options{}, `{"id": "a", "count": 1, "inner": {}}`, []string{"inner.size int32.gt"}},
+	})
+}
+
+// 与PGV一致：只有一个边界时比较该边界；上界大于下界时值在区间内，否则值在区间外
+func TestNumberRange(t *testing.T) {
+	n := func(v int32) *int32 { return &v }
+	cases := []struct {
+		name             string
+		lt, lte, gt, gte *int32
+		pass             []int32
+		fail             []int32
+	}{
+		{"gt", nil, nil, n(5), nil, []int32{6}, []int32{5, 4}},
+		{"gte", nil, nil, nil, n(5), []int32{5, 6}, []int32{4}},
+		{"lt", n(10), nil, nil, nil, []int32{9}, []int32{10, 11}},
+		{"lte", nil, n(10), nil, nil, []int32{9, 10}, []int32{11}},
+		{"gt lt", n(10), nil, n(5), nil, []int32{6, 9}, []int32{5, 10}},
+		{"gte lte", nil, n(10), nil, n(5), []int32{5, 10}, []int32{4, 11}},
+		{"gt lte", nil, n(10), n(5), nil, []int32{6, 10}, []int32{5, 11}},
+		{"gte lt", n(10), nil, nil, n(5), []int32{5, 9}, []int32{4, 10}},
+		{"exclusive gt lt", n(5), nil, n(10), nil, []int32{4, 11}, []int32{5, 7, 10}},
+		{"exclusive gte lte", nil, n(5), nil, n(10), []int32{5, 10, 4, 11}, []int32{6, 9}},
+		{"exclusive gt lte", nil, n(5), n(10), nil, []int32{5, 11}, []int32{6, 10}},
+		{"exclusive gte lt", n(5), nil, nil, n(10), []int32{4, 10}, []int32{5, 9}},
+		{"equal gt lt", n(5), nil, n(5), nil, []int32{4, 6}, []int32{5}},
+		{"equal gte lte", nil, n(5), nil, n(5), []int32{4, 5, 6}, nil},
+	}
+	for _, c := range cases {
+		rule := NumberRange(c.lt, c.lte, c.gt, c.gte)
+		for _, v := range c.pass {
+			if ok, msg := rule(v); !ok {
+				t.Errorf("%s: %d should pass, got %s", c.name, v, msg)
+			}
+		}
+		for _, v := range c.fail {
+			if ok, _ := rule(v); ok {
+				t.Errorf("%s: %d should fail", c.name, v)
+			}
+		}
+	}
+
+	if NumberRange[int32](nil, nil, nil, nil) != nil {
+		t.Error("no bounds: want nil rule")
+	}
+	nan := math.NaN()
+	if ok, _ := NumberRange[float64](nil, nil, &nan, nil)(math.NaN()); !ok {
+		t.Error("NaN should pass")
+	}
+}
+
+func TestNumberRangeMessage(t *testing.T) {
+	n := func(v float64) *float64 { return &v }
+	cases := []struct {
+		lt, lte, gt, gte *float64
+		val              float64
+		want             string
+	}{
+		{n(10), nil, nil, n(5), 10, "数值 10 必须在区间 [5, 10) 内"},
+		{nil, n(5), n(10), nil, 7, "数值 7 必须在区间 (5, 10] 之外"},
+		{nil, nil, n(1.5), nil, 1, "数值 1 必须大于1.5"},
+		{nil, n(1.5), nil, nil, 2, "数值 2 必须小于等于1.5"},
+	}
+	for _, c := range cases {
+		if _, got := NumberRange(c.lt, c.lte, c.gt, c.gte)(c.val); got != c.want {
+			t.Errorf("got %q, want %q", got, c.want)
+		}
+	}
+}
+
+// oneof最多设置一个成员，required时必须设置一个
+func TestValidateOneOf(t *testing.T) {
+	runJSON(t, "presence", "example.Presence", []jsonCase{
+		{"valid", Options{}, `{"count": 1, "email": "a@example.com"}`, nil},
+		{"required", Options{}, `{"count": 1}`, []string{"contact oneof.required"}},
+		{"member", Options{}, `{"count": 1, "phone": "123"}`, []string{"phone string.min_len"}},
+		{"multiple", Options{}, `{"count": 1, "email": "a@example.com", "phone": "12345"}`, []string{"contact oneof"}},
+		{"optional", Options{}, `{"count": 1, "email": "a@example.com", "note": "n", "code": 1}`, []string{"extra oneof"}},
+		{"optional single", Options{}, `{"count": 1, "email": "a@example.com", "note": "n"}`, nil},
+	})
+}
+
+// proto3的隐式存在和显式存在
+func TestValidatePresence(t *testing.T) {
+	runJSON(t, "presence", "example.Presence", []jsonCase{
+		{"implicit zero", Options{}, `{"email": "a@example.com"}`, []string{"count int32.gt"}},
+		{"implicit null", Options{}, `{"count": null, "email": "a@example.com"}`, []string{"count int32.gt"}},
+		{"explicit unset", Options{}, `{"count": 1, "email": "a@example.com", "nickname": null}`, nil},
+		{"explicit set", Options{}, `{"count": 1, "email": "a@example.com", "nickname": ""}`, []string{"nickname string.min_len"}},
+	})
+}
+
+// 顶层不是对象的JSON数据在解码时报错，ValidateData的nil视为空对象
+func TestValidateNullData(t *testing.T) {
+	v := loadValidator(t, "defaults", "example.Defaults", &Options{ApplyDefaults: true})
+	for _, raw := range []string{"null", "[]", "1"} {
+		_, _, err := v.Validate(&Payload{Path: "defaults.json", Format: PayloadJSON, Raw: []byte(raw)})
+		if err == nil {
+			t.Errorf("%s: got nil error, want error", raw)
+		}
+	}
+
+	result := v.ValidateData(nil)
+	if len(result) != 1 || result[0].Path != "port" || result[0].Rule != "int32.gte" {
+		t.Errorf("got %v, want int32.gte at port", result)
+	}
+}
+
+// 默认值写回数据，字节串和枚举与数据中的其他值使用同样的写法，特殊浮点数使用字符串
+func TestApplyDefaults(t *testing.T) {
+	cases := []struct {
+		name  string
+		opts  Options
+		magic string
+		level string
+	}{
+		{"base64", Options{}, `"YQEnYg=="`, `"HIGH"`},
+		{"hex", Options{BytesEncoding: BytesHex}, `"61012762"`, `"HIGH"`},
+		{"raw", Options{BytesEncoding: BytesRaw}, `"a\u0001'b"`, `"HIGH"`},
+		{"enum name", Options{EnumFormat: EnumName}, `"YQEnYg=="`, `"HIGH"`},
+		{"enum number", Options{EnumFormat: EnumNumber}, `"YQEnYg=="`, `2`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.opts.ApplyDefaults = true
+			v := loadValidator(t, "defaults", "example.Defaults", &c.opts)
+			data, result, err := v.Validate(&Payload{Path: "defaults.json", Format: PayloadJSON, Raw: []byte(`{"region": "us"}`)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 默认值同样需要满足校验规则
+			if len(result) != 1 || result[0].Path != "port" || result[0].Rule != "int32.gte" {
+				t.Errorf("got %v, want int32.gte at port", result)
+			}
+
+			path := t.TempDir() + "/defaults.json"
+			if err := WriteNormalized(path, data); err != nil {
+				t.Fatal(err)
+			}
+			out, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := `{
+  "enabled": true,
+  "floor": "-Infinity",
+  "level": ` + c.level + `,
+  "magic": ` + c.magic + `,
+  "missing": "NaN",
+  "port": 80,
+  "ratio": "Infinity",
+  "region": "us"
+}
+`
+			if string(out) != want {
+				t.Errorf("got\n%s\nwant\n%s", out, want)
+			}
+		})
+	}
+
+	// 未开启时不修改数据
+	v := loadValidator(t, "defaults", "example.Defaults", &Options{})
+	data, _, err := v.Validate(&Payload{Path: "defaults.json", Format: PayloadJSON, Raw: []byte(`{}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0 {
+		t.Errorf("got %v, want no defaults", data)
+	}
+}
+
+// Timestamp、Duration、Any以及包装类型的规则
+func TestValidateWellKnown(t *testing.T) {
+	now := time.Now().UTC()
+	stamp := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+	duration := `"type.googleapis.com/google.protobuf.Duration"`
+	runJSON(t, "well_known", "example.WellKnown", []jsonCase{
+		{"valid", Options{}, `{"timeout": "30s"}`, nil},
+		{"required", Options{}, `{}`, []string{"timeout duration.required"}},
+
+		{"lt_now", Options{}, `{"timeout": "1s", "past": "` + stamp(-time.Minute) + `"}`, nil},
+		{"lt_now future", Options{}, `{"timeout": "1s", "past": "` + stamp(time.Hour) + `"}`, []string{"past timestamp.lt_now"}},
+		{"gt_now", Options{}, `{"timeout": "1s", "future": "` + stamp(time.Hour) + `"}`, nil},
+		{"gt_now past", Options{}, `{"timeout": "1s", "future": "` + stamp(-time.Minute) + `"}`, []string{"future timestamp.gt_now"}},
+		{"within", Options{}, `{"timeout": "1s", "recent": "` + stamp(-time.Minute) + `"}`, nil},
+		{"within future", Options{}, `{"timeout": "1s", "recent": "` + stamp(time.Minute) + `"}`, nil},
+		{"within far", Options{}, `{"timeout": "1s", "recent": "` + stamp(-2*time.Hour) + `"}`, []string{"recent timestamp.within"}},
+		{"range", Options{}, `{"timeout": "1s", "period": "2000-01-01T00:00:00Z"}`, nil},
+		{"range before", Options{}, `{"timeout": "1s", "period": "1999-12-31T23:59:59.999Z"}`, []string{"period timestamp.gte_lt"}},
+		{"range after", Options{}, `{"timeout": "1s", "period": "2100-01-01T00:00:00Z"}`, []string{"period timestamp.gte_lt"}},
+		{"timestamp type", Options{}, `{"timeout": "1s", "period": "2000-01-01"}`, []string{"period type"}},
+
+		{"duration zero", Options{}, `{"timeout": "0s"}`, []string{"timeout duration.gt_lte"}},
+		{"duration max", Options{}, `{"timeout": "60s"}`, nil},
+		{"duration over", Options{}, `{"timeout": "60.000000001s"}`, []string{"timeout duration.gt_lte"}},
+		{"duration type", Options{}, `{"timeout": 30}`, []string{"timeout type"}},
+
+		{"any in", Options{}, `{"timeout": "1s", "detail": {"@type": ` + duration + `, "value": "1s"}}`, nil},
+		{"any not in", Options{}, `{"timeout": "1s", "detail": {"@type": "type.googleapis.com/google.protobuf.Empty"}}`, []string{"detail any.in"}},
+		{"any not_in", Options{}, `{"timeout": "1s", "extra": {"@type": ` + duration + `}}`, nil},
+		{"any in not_in", Options{}, `{"timeout": "1s", "extra": {"@type": "type.googleapis.com/google.protobuf.Empty"}}`, []string{"extra any.not_in"}},
+		{"any type", Options{}, `{"timeout": "1s", "detail": {"value": "1s"}}`, []string{"detail type"}},
+
+		{"wrappers", Options{}, `{"timeout": "1s", "retries": 5, "name": "ab", "enabled": true, "token": "YWI="}`, nil},
+		{"wrappers null", Options{}, `{"timeout": "1s", "retries": null, "name": null, "enabled": null, "token": null}`, nil},
+		{"wrappers invalid", Options{}, `{"timeout": "1s", "retries": 6, "name": "a", "enabled": false, "token": "YWJj"}`,
+			[]string{"retries int32.gte_lte", "name string.min_len", "enabled bool.const", "token bytes.len"}},
+		{"wrapper type", Options{}, `{"timeout": "1s", "retries": "3"}`, []string{"retries type"}},
+		{"wrapper lenient", Options{Lenient: true}, `{"timeout": "1s", "retries": "3"}`, nil},
+
+		{"struct", Options{}, `{"timeout": "1s", "meta": {"a": [1, {"b": null}], "c": "d"}}`, nil},
+	})
+}
+
+func TestConvertDuration(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Duration
+		err  bool
+	}{
+		{"1.5s", Duration{1, 500000000}, false},
+		{"-0.000000001s", Duration{0, -1}, false},
+		{"315576000000s", Duration{315576000000, 0}, false},
+		{"-315576000000.5s", Duration{-315576000000, -500000000}, false},
+		{"315576000001s", Duration{}, true},
+		{"1m", Duration{}, true},
+		{"1.0000000001s", Duration{}, true},
+	}
+	for _, c := range cases {
+		got, err := toDuration(c.in, false)
+		if (err != nil) != c.err || got != c.want {
+			t.Errorf("toDuration(%q) = %v, %v, want %v", c.in, got, err, c.want)
+		}
+	}
+}
+
+// 规则有误时编译失败，嵌套消息中的错误也要报告，失败的编译结果不会被缓存
+func TestCompileInvalidRules(t *testing.T) {
+	req, err := os.ReadFile("../testdata/pb_bin/invalid_rules.pb.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	descs, err := LoadDescriptors(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		message string
+		want    string
+	}{
+		{"example.Pattern", "example.Pattern.name"},
+		{"example.Items", "example.Items.data"},
+		{"example.Protocol", "example.Pattern.name"},
+		{"example.Protocol", "example.Pattern.name"},
+	}
+	for _, c := range cases {
+		_, err := descs.Compile(c.message, &Options{})
+		if err == nil || !strings.Contains(err.Error(), c.want) || !strings.Contains(err.Error(), "正则表达式错误") {
+			t.Errorf("Compile(%s) = %v, want error of %s", c.message, err, c.want)
+		}
+	}
+}
+
+// 与BenchmarkValidateDataFaceInfo对比：每次校验都重新编译规则，即没有校验计划时的开销
+func BenchmarkCompilePerCallFaceInfo(b *testing.B) {
+	v := loadValidator(b, "tango_verify_result_verify", "example.FaceInfo", &Options{})
+	data, err := faceInfoPayload(b).Decode(v.desc)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p, err := compileMessage(v.plan.message, v.desc, make(map[string]*messagePlan))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if result := p.check(data, "", &Options{}); !result.Valid() {
+			b.Fatal(result)
+		}
+	}
+}
+
+// 文本格式的数据与JSON走同样的校验流程，错误指向同样的路径
+func TestValidateText(t *testing.T) {
+	v := loadValidator(t, "tango_verify_result_verify", "example.Protocol", &Options{})
+	payload := loadPayload(t, "tango_verify_result_verify.txtpb")
+	if payload.Format != PayloadText {
+		t.Fatalf("got format %s, want %s", payload.Format, PayloadText)
+	}
+	data, result, err := v.Validate(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid() {
+		t.Errorf("got %v, want valid", result)
+	}
+	// 枚举解码为数值，与二进制数据一致
+	if got := data["data"].(map[string]any)["verify_type"]; got != json.Number("2") {
+		t.Errorf("verify_type = %#v, want 2", got)
+	}
+
+	payload.Raw = bytes.Replace(payload.Raw, []byte(`ip: "192.168.1.10"`), []byte(`ip: "192.168.1"`), 1)
+	_, result, err = v.Validate(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].Path != "data.face_info.ip" || result[0].Rule != "string.ip" {
+		t.Errorf("got %v, want string.ip at data.face_info.ip", result)
+	}
+}
+
+func TestDecodeTextError(t *testing.T) {
+	v := loadValidator(t, "tango_verify_result_verify", "example.Protocol", &Options{})
+	cases := []struct {
+		name         string
+		raw          string
+		line, column int
+	}{
+		{"unknown field", "name: \"a\"\ndata: {\n  spid: \"1\"\n  bogus: 1\n}\n", 4, 3},
+		{"syntax", "name: \"a\"\ndata: {\n  spid: \"1\" ]\n}\n", 3, 13},
+		{"type", "name: \"a\"\n  version: 1.0\n", 2, 12},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, _, err := v.Validate(&Payload{Path: "verify.txtpb", Format: PayloadText, Raw: []byte(c.raw)})
+			var text_err *TextError
+			if !errors.As(err, &text_err) {
+				t.Fatalf("got %v, want TextError", err)
+			}
+			if text_err.Line != c.line || text_err.Column != c.column {
+				t.Errorf("got %d:%d, want %d:%d (%v)", text_err.Line, text_err.Column, c.line, c.column, err)
+			}
+		})
+	}
+}
+
+// 二进制数据的编码错误指出字节偏移和所在的字段
+func TestDecodeWireError(t *testing.T) {
+	v := loadValidator(t, "simple", "example.Protocol", &Options{})
+	cases := []struct {
+		name   string
+		raw    string
+		offset int
+		path   string
+	}{
+		{"bad tag", "\x18\x0a\xff", 2, ""},
+		{"bad varint", "\x18\x0a\x18\xff\xff", 3, "int32_val"},
+		{"truncated bytes", "\x72\x05ab", 1, "string_val"},
+		{"packed", "\x92\x01\x03\x01\xff\xff", 4, "verify_types[1]"},
+		{"unknown field", "\xa8\x06\xff", 2, "101"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, _, err := v.Validate(&Payload{Path: "simple.bin", Format: PayloadBinary, Raw: []byte(c.raw)})
+			var wire_err *WireError
+			if !errors.As(err, &wire_err) {
+				t.Fatalf("got %v, want WireError", err)
+			}
+			if wire_err.Offset != c.offset || wire_err.Path != c.path {
+				t.Errorf("got offset %d path %q, want offset %d path %q", wire_err.Offset, wire_err.Path, c.offset, c.path)
+			}
+		})
+	}
+}
+
+// 二进制数据中的未知字段不出现在解码后的数据中，只在严格模式下报告
+func TestValidateUnknownWireFields(t *testing.T) {
+	payload := loadPayload(t, "tango_verify_result_verify.bin")
+	payload.Raw = protowire.AppendVarint(protowire.AppendTag(payload.Raw, 99, protowire.VarintType), 1)
+
+	for _, strict := range []bool{false, true} {
+		v := loadValidator(t, "tango_verify_result_verify", "example.Protocol", &Options{Strict: strict})
+		data, result, err := v.Validate(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := data["99"]; ok {
+			t.Errorf("strict=%v: unknown field 99 in decoded data", strict)
+		}
+		if !strict && !result.Valid() {
+			t.Errorf("strict=%v: got %v, want valid", strict, result)
+		}
+		if strict && (len(result) != 1 || result[0].Path != "99" || result[0].Rule != "unknown_field") {
+			t.Errorf("strict=%v: got %v, want unknown_field at 99", strict, result)
+		}
+	}
+}
+
+// 三种报告格式的内容，JSON数据中的数值在报告中仍为数值
+func TestWriteReport(t *testing.T) {
+	payload := &Payload{Path: "presence.json", Format: PayloadJSON, Raw: []byte("{\n  \"email\": \"a@example.com\",\n  \"count\": -1\n}\n")}
+	v := loadValidator(t, "presence", "example.Presence", &Options{})
+	_, result, err := v.Validate(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Report{Message: "example.Presence", Payload: payload, Result: result}
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := WriteReport(&out, FormatJSON, report); err != nil {
+			t.Fatal(err)
+		}
+		var got struct {
+			Valid      bool
+			Violations []struct {
+				Path  string
+				Rule  string
+				Param any
+				Value any
+			}
+		}
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Valid || len(got.Violations) != 1 {
+			t.Fatalf("got %s, want one violation", out.Bytes())
+		}
+		if vio := got.Violations[0]; vio.Path != "count" || vio.Rule != "int32.gt" || vio.Param != 0.0 || vio.Value != -1.0 {
+			t.Errorf("got %+v, want numeric param 0 and value -1 at count", vio)
+		}
+	})
+
+	t.Run("junit", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := WriteReport(&out, FormatJUnit, report); err != nil {
+			t.Fatal(err)
+		}
+		var got junitTestSuites
+		if err := xml.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Suites) != 1 || got.Suites[0].Failures != 1 || len(got.Suites[0].Cases) != 1 {
+			t.Fatalf("got %s, want one failed case", out.Bytes())
+		}
+		c := got.Suites[0].Cases[0]
+		if c.Name != "presence.json" || c.Classname != "example.Presence" || c.Failure == nil || !strings.HasPrefix(c.Failure.Text, "count: [int32.gt]") {
+			t.Errorf("got %+v, want failure at count", c)
+		}
+	})
+
+	t.Run("sarif", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := WriteReport(&out, FormatSARIF, report); err != nil {
+			t.Fatal(err)
+		}
+		var got struct {
+			Runs []struct {
+				Results []struct {
+					RuleID    string
+					Locations []struct {
+						PhysicalLocation struct {
+							ArtifactLocation struct{ URI string }
+							Region           struct{ StartLine int }
+						}
+					}
+				}
+			}
+		}
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Runs) != 1 || len(got.Runs[0].Results) != 1 || len(got.Runs[0].Results[0].Locations) != 1 {
+			t.Fatalf("got %s, want one result", out.Bytes())
+		}
+		r := got.Runs[0].Results[0]
+		if loc := r.Locations[0].PhysicalLocation; r.RuleID != "int32.gt" || loc.ArtifactLocation.URI != "presence.json" || loc.Region.StartLine != 3 {
+			t.Errorf("got %+v, want int32.gt at presence.json:3", r)
+		}
+	})
+}
+
+func TestClosestName(t *testing.T) {
+	names := []string{"ip", "purchaser_uid", "purchaserUid", "verify_types"}
+	cases := []struct {
+		key  string
+		want string
+	}{
+		{"pi", "ip"},
+		{"ipp", "ip"},
+		{"zzzzzz", ""},
+		{"purchaser_id", "purchaser_uid"},
+		{"purchaseruid", "purchaser_uid"}, // 距离相同时取先出现的
+		{"verify_tpyes", "verify_types"},
+		{"verify", ""},
+	}
+	for _, c := range cases {
+		if got := closestName(c.key, names); got != c.want {
+			t.Errorf("closestName(%q) = %q, want %q", c.key, got, c.want)
+		}
+	}
+}
+
+// 空元素因 ignore_empty 跳过时，字段的调试信息仍然输出执行过的规则
+func TestRepeatedTraceIgnoreEmpty(t *testing.T) {
+	rules := &validate.StringRules{MinLen: proto.Uint64(2), IgnoreEmpty: proto.Bool(true)}
+	items, err := compileValue("string", nil, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &repeatedPlan{items: items}
+
+	tr := newFieldTrace("tags", "TYPE_STRING")
+	if result := p.check([]any{"", "abc"}, "tags", &Options{}, tr); !result.Valid() {
+		t.Fatalf("got %v, want valid", result)
+	}
+	if got, want := tr.String(), "optional TYPE_STRING tags = [ abc] [min_len=2,]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
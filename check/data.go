@@ -0,0 +1,320 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// 待校验数据的格式
+const (
+	PayloadJSON   = "json"   // JSON
+	PayloadBinary = "binary" // protobuf二进制编码
+	PayloadText   = "text"   // protobuf文本格式
+)
+
+// 待校验的数据
+type Payload struct {
+	Path   string
+	Format string
+	Raw    []byte
+}
+
+// 读取待校验的数据，path 为 "-" 时从标准输入读取。
+// format 为空时根据文件后缀推断，标准输入默认为JSON
+func ReadPayload(path string, format string) (*Payload, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "" {
+		format = payloadFormat(path)
+	}
+	switch format {
+	case PayloadJSON, PayloadBinary, PayloadText:
+	default:
+		return nil, fmt.Errorf("不支持的数据格式 %s", format)
+	}
+	return &Payload{Path: path, Format: format, Raw: raw}, nil
+}
+
+// 根据文件后缀推断数据格式
+func payloadFormat(path string) string {
+	switch filepath.Ext(path) {
+	case ".bin", ".pb", ".binpb":
+		return PayloadBinary
+	case ".txtpb", ".textproto":
+		return PayloadText
+	default:
+		return PayloadJSON
+	}
+}
+
+// 将数据解析为JSON对象，二进制和文本格式的数据按照根消息的描述符解码
+func (p *Payload) Decode(md protoreflect.MessageDescriptor) (map[string]any, error) {
+	data, _, err := p.decode(md)
+	return data, err
+}
+
+// 同时返回二进制数据解码得到的消息，用于报告未知字段，其他格式为nil
+func (p *Payload) decode(md protoreflect.MessageDescriptor) (map[string]any, protoreflect.Message, error) {
+	switch p.Format {
+	case PayloadBinary:
+		return decodeWire(p.Raw, md)
+	case PayloadText:
+		data, err := decodeText(p.Raw, md)
+		return data, nil, err
+	default:
+		data, err := decodeJSON(p.Raw, p.Path)
+		return data, nil, err
+	}
+}
+
+// 转义的代理项，如 \ud800。成对出现时是合法的字符，单独出现时不是合法的UTF-8
+var surrogateEscape = regexp.MustCompile(`\\u[dD][89abcdefABCDEF]`)
+
+func decodeJSON(raw []byte, path string) (map[string]any, error) {
+	// 数值保留为json.Number，避免64位整数丢失精度
+	data := make(map[string]any)
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("解析数据 %s 失败: %w", path, err)
+	}
+	// 顶层为null时解码得到nil，其他非对象的值已由Decode报告
+	if data == nil {
+		return nil, fmt.Errorf("解析数据 %s 失败: 顶层不是JSON对象", path)
+	}
+
+	// JSON解码会把非法的UTF-8和单独的代理项替换为U+FFFD。
+	// 这种情况下重新解码并保留字符串的原始内容，由字符串字段的校验报告错误
+	if utf8.Valid(raw) && !surrogateEscape.Match(raw) {
+		return data, nil
+	}
+	d := &jsonScanner{data: raw}
+	val, err := d.value()
+	if err != nil {
+		return nil, fmt.Errorf("解析数据 %s 失败: %w", path, err)
+	}
+	return val.(map[string]any), nil
+}
+
+/*
+*
+
+	保留字符串原始内容的JSON解码，只在数据包含非法UTF-8时使用
+	1. 语法已经由encoding/json检查过，这里只按照同样的结构重新解码
+	2. 非法的字节原样保留，单独的代理项按照WTF-8编码，都不是合法的UTF-8
+*/
+type jsonScanner struct {
+	data []byte
+	off  int
+}
+
+func (d *jsonScanner) skipSpace() {
+	for d.off < len(d.data) && strings.IndexByte(" \t\r\n", d.data[d.off]) >= 0 {
+		d.off++
+	}
+}
+
+func (d *jsonScanner) value() (any, error) {
+	d.skipSpace()
+	if d.off >= len(d.data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	switch c := d.data[d.off]; {
+	case c == '{':
+		return d.object()
+	case c == '[':
+		return d.array()
+	case c == '"':
+		return d.str()
+	case bytes.HasPrefix(d.data[d.off:], []byte("true")):
+		d.off += 4
+		return true, nil
+	case bytes.HasPrefix(d.data[d.off:], []byte("false")):
+		d.off += 5
+		return false, nil
+	case bytes.HasPrefix(d.data[d.off:], []byte("null")):
+		d.off += 4
+		return nil, nil
+	default:
+		start := d.off
+		for d.off < len(d.data) && strings.IndexByte("+-0123456789.eE", d.data[d.off]) >= 0 {
+			d.off++
+		}
+		if start == d.off {
+			return nil, fmt.Errorf("偏移 %d 处不是合法的JSON值", start)
+		}
+		return json.Number(d.data[start:d.off]), nil
+	}
+}
+
+// 期望下一个非空白字符为c
+func (d *jsonScanner) expect(c byte) error {
+	d.skipSpace()
+	if d.off >= len(d.data) || d.data[d.off] != c {
+		return fmt.Errorf("偏移 %d 处应为 %q", d.off, c)
+	}
+	d.off++
+	return nil
+}
+
+// 与encoding/json一致，重复的键以最后一个为准
+func (d *jsonScanner) object() (map[string]any, error) {
+	obj := make(map[string]any)
+	d.off++
+	if d.skipSpace(); d.off < len(d.data) && d.data[d.off] == '}' {
+		d.off++
+		return obj, nil
+	}
+	for {
+		if err := d.expect('"'); err != nil {
+			return nil, err
+		}
+		d.off--
+		key, err := d.str()
+		if err != nil {
+			return nil, err
+		}
+		if err := d.expect(':'); err != nil {
+			return nil, err
+		}
+		if obj[key], err = d.value(); err != nil {
+			return nil, err
+		}
+		if d.skipSpace(); d.off < len(d.data) && d.data[d.off] == ',' {
+			d.off++
+			continue
+		}
+		return obj, d.expect('}')
+	}
+}
+
+func (d *jsonScanner) array() ([]any, error) {
+	arr := []any{}
+	d.off++
+	if d.skipSpace(); d.off < len(d.data) && d.data[d.off] == ']' {
+		d.off++
+		return arr, nil
+	}
+	for {
+		item, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, item)
+		if d.skipSpace(); d.off < len(d.data) && d.data[d.off] == ',' {
+			d.off++
+			continue
+		}
+		return arr, d.expect(']')
+	}
+}
+
+var jsonEscapes = map[byte]byte{'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t'}
+
+func (d *jsonScanner) str() (string, error) {
+	start := d.off
+	d.off++
+	var buf []byte
+	for d.off < len(d.data) {
+		c := d.data[d.off]
+		switch {
+		case c == '"':
+			d.off++
+			return string(buf), nil
+		case c != '\\':
+			buf = append(buf, c)
+			d.off++
+		case d.off+1 < len(d.data) && d.data[d.off+1] != 'u':
+			esc, ok := jsonEscapes[d.data[d.off+1]]
+			if !ok {
+				return "", fmt.Errorf("偏移 %d 处的转义不合法", d.off)
+			}
+			buf = append(buf, esc)
+			d.off += 2
+		default:
+			r, ok := d.hex4(d.off + 2)
+			if !ok {
+				return "", fmt.Errorf("偏移 %d 处的转义不合法", d.off)
+			}
+			d.off += 6
+			// 代理对组合为一个字符
+			if utf16.IsSurrogate(r) && bytes.HasPrefix(d.data[d.off:], []byte("\\u")) {
+				if r2, ok := d.hex4(d.off + 2); ok {
+					if dec := utf16.DecodeRune(r, r2); dec != utf8.RuneError {
+						buf = utf8.AppendRune(buf, dec)
+						d.off += 6
+						continue
+					}
+				}
+			}
+			if utf16.IsSurrogate(r) {
+				// utf8.AppendRune会替换为U+FFFD，这里直接写入三个字节
+				buf = append(buf, byte(0xE0|r>>12), byte(0x80|(r>>6)&0x3F), byte(0x80|r&0x3F))
+				continue
+			}
+			buf = utf8.AppendRune(buf, r)
+		}
+	}
+	return "", fmt.Errorf("偏移 %d 处的字符串没有结束", start)
+}
+
+// 解析 \u 之后的四位十六进制数
+func (d *jsonScanner) hex4(off int) (rune, bool) {
+	if off+4 > len(d.data) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(d.data[off:off+4]), 16, 16)
+	return rune(n), err == nil
+}
+
+// 将校验后的数据（包括写入的默认值）以JSON格式输出，"-" 表示输出到标准输出。
+// 字节串按照protojson的约定使用base64编码
+func WriteNormalized(path string, data map[string]any) error {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	out = append(out, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return os.WriteFile(path, out, 0644)
+}
+
+// 按照文本格式输出校验结果，路径相同的连续错误归为一组
+func outputValidationResult(w io.Writer, result ValidationResult) {
+	for i, v := range result {
+		if i == 0 || result[i-1].Path != v.Path {
+			fmt.Fprintln(w, "name :", v.Path)
+		}
+		fmt.Fprintln(w, v.Message)
+		if i == len(result)-1 || result[i+1].Path != v.Path {
+			fmt.Fprintln(w, "-------------")
+		}
+	}
+}
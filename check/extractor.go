@@ -0,0 +1,56 @@
+package check
+
+import (
+	"reflect"
+)
+
+func getBool(val reflect.Value, name string) (bool, bool) {
+	field := val.FieldByName(name)
+	if field.IsValid() {
+		v, ok := field.Interface().(*bool)
+		if ok && v != nil {
+			return true, *v
+		}
+	}
+	return false, false
+}
+
+func getFieldPointer[T any](val reflect.Value, name string) (bool, T) {
+	var zero T
+	field := val.FieldByName(name)
+	if field.IsValid() {
+		v, ok := field.Interface().(*T)
+		if ok && v != nil {
+			return true, *v
+		}
+	}
+	return false, zero
+}
+
+func getFieldArray[T any](val reflect.Value, name string) (bool, []T) {
+	field := val.FieldByName(name)
+	if field.IsValid() {
+		v, ok := field.Interface().([]T)
+		if ok && v != nil {
+			return true, v
+		}
+	}
+	return false, nil
+}
+
+// 判断val中名称为oneof的oneof字段是否设置为名称为name的bool选项（如 WellKnown 中的 Ip）。
+// 如果有，返回true, 取值。否则返回false, false
+func getOneofBool(val reflect.Value, oneof string, name string) (bool, bool) {
+	field := val.FieldByName(oneof)
+	if !field.IsValid() || field.IsNil() {
+		return false, false
+	}
+	option := field.Elem().Elem().FieldByName(name)
+	if option.IsValid() {
+		v, ok := option.Interface().(bool)
+		if ok {
+			return true, v
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,758 @@
+package check
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/envoyproxy/protoc-gen-validate/templates/shared"
+	"github.com/envoyproxy/protoc-gen-validate/validate"
+	pgs "github.com/lyft/protoc-gen-star/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// 校验选项
+type Options struct {
+	Lenient    bool   // 宽松模式：允许数值、布尔值以字符串形式给出
+	EnumFormat string // 枚举值的写法：name 只允许名称，number 只允许数值，为空时两者均可
+
+	BytesEncoding string // JSON中字节串的编码：base64（默认，与protojson一致）、hex、raw
+
+	ApplyDefaults bool // 未设置的可选字段使用proto2中 [default = ...] 声明的默认值，并写回数据中
+	Strict        bool // 严格模式：数据中不属于消息字段的键视为错误
+
+	Trace func(*FieldTrace) // 每个字段校验完成后调用，用于输出调试信息，为nil时不记录。并发校验时需要自行同步
+}
+
+// 枚举值的写法
+const (
+	EnumName   = "name"
+	EnumNumber = "number"
+)
+
+// JSON中字节串的编码
+const (
+	BytesBase64 = "base64"
+	BytesHex    = "hex"
+	BytesRaw    = "raw"
+)
+
+// 消息设置了 (validate.disabled) 或 (validate.ignored) 时不校验
+func messageDisabled(m pgs.Message) bool {
+	var disabled, ignored bool
+	if _, err := m.Extension(validate.E_Disabled, &disabled); err != nil {
+		return false
+	}
+	if _, err := m.Extension(validate.E_Ignored, &ignored); err != nil {
+		return false
+	}
+	return disabled || ignored
+}
+
+func skipEmbedded(ruleContext shared.RuleContext) bool {
+	switch rules := ruleContext.Rules.(type) {
+	case *validate.RepeatedRules:
+		return rules.GetItems().GetMessage().GetSkip()
+	case *validate.MapRules:
+		return rules.GetValues().GetMessage().GetSkip()
+	}
+	return ruleContext.MessageRules.GetSkip()
+}
+
+// 普通的嵌套消息。Timestamp、Duration、Any以及包装类型等WKT在JSON中不是普通对象，按值校验，不递归
+func isPlainEmbed(typ ruleFieldType) bool {
+	return typ.IsEmbed() && !typ.Embed().IsWellKnown() && !isJSONValue(typ.Embed())
+}
+
+// Struct、Value、ListValue、FieldMask、Empty在JSON中可以是任意形式的值，不校验类型，也不递归。
+// pgs不把FieldMask视为WKT，按照全限定名判断
+func isJSONValue(m pgs.Message) bool {
+	switch m.WellKnownType() {
+	case pgs.StructWKT, pgs.ValueWKT, pgs.ListValueWKT, pgs.EmptyWKT:
+		return true
+	}
+	return m.FullyQualifiedName() == ".google.protobuf.FieldMask"
+}
+
+// 字段在数据中可用的键：proto字段名，以及protojson使用的json_name。
+// json_name默认为小驼峰形式，也可以在proto文件中通过json_name选项自定义
+func fieldKeys(f pgs.Field) []string {
+	name := f.Name().String()
+	jsonName := f.Descriptor().GetJsonName()
+	if jsonName == "" {
+		jsonName = jsonCamelCase(name)
+	}
+	if jsonName == name {
+		return []string{name}
+	}
+	return []string{name, jsonName}
+}
+
+// purchaser_uid -> purchaserUid，与protoc生成默认json_name的规则一致
+func jsonCamelCase(s string) string {
+	var b strings.Builder
+	upper := false
+	for _, c := range s {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+/*
+*
+
+	字段的存在性(presence)，语法和editions的features已由protoreflect解析
+	1. proto2的required字段，以及editions中的LEGACY_REQUIRED，必须设置
+	2. repeated和map没有存在性，未设置即为空
+	3. 其余字段：显式存在时未设置即跳过，隐式存在时按零值校验。
+	   oneof成员（包括proto3的optional）和嵌套消息总是显式存在
+*/
+func fieldPresence(fd protoreflect.FieldDescriptor) descriptorpb.FeatureSet_FieldPresence {
+	switch {
+	case fd.Cardinality() == protoreflect.Required:
+		return descriptorpb.FeatureSet_LEGACY_REQUIRED
+	case fd.HasPresence():
+		return descriptorpb.FeatureSet_EXPLICIT
+	}
+	return descriptorpb.FeatureSet_IMPLICIT
+}
+
+// 隐式存在的字段未设置时的零值，使用数据中的写法表示
+func zeroValue(f pgs.Field, opts *Options) any {
+	switch {
+	case f.Type().IsRepeated():
+		return []any{}
+	case f.Type().IsMap():
+		return map[string]any{}
+	}
+
+	switch f.Type().ProtoType() {
+	case pgs.StringT:
+		return ""
+	case pgs.BytesT:
+		return []byte{}
+	case pgs.BoolT:
+		return false
+	case pgs.EnumT:
+		// 枚举的零值是第一个枚举值
+		first := f.Type().Enum().Values()[0]
+		if opts.EnumFormat == EnumName {
+			return first.Name().String()
+		}
+		return json.Number(strconv.Itoa(int(first.Value())))
+	}
+	return json.Number("0")
+}
+
+// 描述符中的默认值是文本形式，转换为数据中的写法
+func defaultValue(f pgs.Field, def string, opts *Options) (any, error) {
+	switch f.Type().ProtoType() {
+	case pgs.StringT:
+		return def, nil
+	case pgs.BytesT:
+		// 与数据中其他字节串的编码一致，输出的数据中不会混用多种编码
+		b, err := unescapeBytes(def)
+		if err != nil {
+			return nil, err
+		}
+		switch opts.BytesEncoding {
+		case BytesHex:
+			return hex.EncodeToString(b), nil
+		case BytesRaw:
+			return string(b), nil
+		}
+		return b, nil
+	case pgs.BoolT:
+		return def == "true", nil
+	case pgs.EnumT:
+		if opts.EnumFormat != EnumNumber {
+			return def, nil
+		}
+		for _, ev := range f.Type().Enum().Values() {
+			if ev.Name().String() == def {
+				return json.Number(strconv.Itoa(int(ev.Value()))), nil
+			}
+		}
+		return nil, fmt.Errorf("枚举 %s 没有名为 %s 的值", f.Type().Enum().Name(), def)
+	case pgs.FloatT, pgs.DoubleT:
+		// 特殊浮点数按照protojson的约定使用字符串
+		switch def {
+		case "inf":
+			return "Infinity", nil
+		case "-inf":
+			return "-Infinity", nil
+		case "nan":
+			return "NaN", nil
+		}
+	}
+	return json.Number(def), nil
+}
+
+// 字节串的默认值使用C风格的转义，如 "a\001\'"
+func unescapeBytes(s string) ([]byte, error) {
+	var b []byte
+	for len(s) > 0 {
+		if strings.HasPrefix(s, `\'`) {
+			b, s = append(b, '\''), s[2:]
+			continue
+		}
+		c, multibyte, tail, err := strconv.UnquoteChar(s, '"')
+		if err != nil {
+			return nil, err
+		}
+		if !multibyte && c < 256 {
+			b = append(b, byte(c))
+		} else {
+			b = utf8.AppendRune(b, c)
+		}
+		s = tail
+	}
+	return b, nil
+}
+
+// 嵌套消息的 message.required，以及Timestamp、Duration、Any的required规则，要求字段必须设置
+// 返回规则id，不要求设置时为空
+func ruleRequired(f pgs.Field, ruleContext shared.RuleContext) string {
+	if !f.Type().IsEmbed() {
+		return ""
+	}
+	if ruleContext.MessageRules.GetRequired() {
+		return "message.required"
+	}
+	switch rules := ruleContext.Rules.(type) {
+	case *validate.TimestampRules:
+		if rules.GetRequired() {
+			return "timestamp.required"
+		}
+	case *validate.DurationRules:
+		if rules.GetRequired() {
+			return "duration.required"
+		}
+	case *validate.AnyRules:
+		if rules.GetRequired() {
+			return "any.required"
+		}
+	}
+	return ""
+}
+
+func parseBool(bool_rules protoreflect.ProtoMessage) []namedRule[bool] {
+	val := getValue(bool_rules)
+	var rules []namedRule[bool]
+
+	rules = addRule[bool, bool]("Const", ScalarConst)(val, rules)
+	return rules
+}
+
+func parseString(string_rules protoreflect.ProtoMessage) ([]namedRule[string], error) {
+	val := getValue(string_rules)
+	var rules []namedRule[string]
+
+	rules = addRule[string, string]("Const", ScalarConst)(val, rules)
+	rules = addRule[string, uint64]("Len", StringLen)(val, rules)
+	rules = addRule[string, uint64]("MinLen", StringMinLen)(val, rules)
+	rules = addRule[string, uint64]("MaxLen", StringMaxLen)(val, rules)
+	rules = addRule[string, uint64]("LenBytes", StringLenBytes)(val, rules)
+	rules = addRule[string, uint64]("MinBytes", StringMinBytes)(val, rules)
+	rules = addRule[string, uint64]("MaxBytes", StringMaxBytes)(val, rules)
+	rules, err := addPatternRule(StringPattern)(val, rules)
+	if err != nil {
+		return nil, err
+	}
+	rules = addRule[string, string]("Prefix", StringPrefix)(val, rules)
+	rules = addRule[string, string]("Suffix", StringSuffix)(val, rules)
+	rules = addRule[string, string]("Contains", StringContains)(val, rules)
+	rules = addRule[string, string]("NotContains", StringNotContains)(val, rules)
+	rules = addInRule(val, rules)
+	rules = addNotInRule(val, rules)
+	rules = addWellKnownRule("Email", StringEmail)(val, rules)
+	rules = addWellKnownRule("Hostname", StringHostname)(val, rules)
+	rules = addWellKnownRule("Ip", StringIP)(val, rules)
+	rules = addWellKnownRule("Ipv4", StringIPv4)(val, rules)
+	rules = addWellKnownRule("Ipv6", StringIPv6)(val, rules)
+	rules = addWellKnownRule("Address", StringAddress)(val, rules)
+	rules = addWellKnownRule("Uri", StringURI)(val, rules)
+	rules = addWellKnownRule("UriRef", StringURIRef)(val, rules)
+	rules = addWellKnownRule("Uuid", StringUUID)(val, rules)
+	rules = addWellKnownRegexRule(string_rules.(*validate.StringRules), rules)
+	return rules, nil
+}
+
+func parseBytes(bytes_rules protoreflect.ProtoMessage) ([]namedRule[[]byte], error) {
+	val := getValue(bytes_rules)
+	var rules []namedRule[[]byte]
+
+	rules = addSliceRule[[]byte, byte]("Const", BytesConst)(val, rules)
+	rules = addRule[[]byte, uint64]("Len", BytesLen)(val, rules)
+	rules = addRule[[]byte, uint64]("MinLen", BytesMinLen)(val, rules)
+	rules = addRule[[]byte, uint64]("MaxLen", BytesMaxLen)(val, rules)
+	rules, err := addPatternRule(BytesPattern)(val, rules)
+	if err != nil {
+		return nil, err
+	}
+	rules = addSliceRule[[]byte, byte]("Prefix", BytesPrefix)(val, rules)
+	rules = addSliceRule[[]byte, byte]("Suffix", BytesSuffix)(val, rules)
+	rules = addSliceRule[[]byte, byte]("Contains", BytesContains)(val, rules)
+	rules = addSliceRule[[]byte, []byte]("In", BytesIn)(val, rules)
+	rules = addSliceRule[[]byte, []byte]("NotIn", BytesNotIn)(val, rules)
+	rules = addWellKnownRule("Ip", BytesIP)(val, rules)
+	rules = addWellKnownRule("Ipv4", BytesIPv4)(val, rules)
+	rules = addWellKnownRule("Ipv6", BytesIPv6)(val, rules)
+	return rules, nil
+}
+
+func parseTimestamp(timestamp_rules protoreflect.ProtoMessage) []namedRule[Timestamp] {
+	r := timestamp_rules.(*validate.TimestampRules)
+	var rules []namedRule[Timestamp]
+
+	if r.Const != nil {
+		rules = append(rules, newRule("timestamp.const", *ruleTimestamp(r.Const), TimestampConst(*ruleTimestamp(r.Const))))
+	}
+	rules = addTimestampRangeRule(r, rules)
+	if r.GetLtNow() {
+		rules = append(rules, newRule("timestamp.lt_now", true, TimestampLtNow()))
+	}
+	if r.GetGtNow() {
+		rules = append(rules, newRule("timestamp.gt_now", true, TimestampGtNow()))
+	}
+	if r.Within != nil {
+		rules = append(rules, newRule("timestamp.within", r.Within.AsDuration(), TimestampWithin(r.Within.AsDuration())))
+	}
+	return rules
+}
+
+func parseDuration(duration_rules protoreflect.ProtoMessage) []namedRule[Duration] {
+	r := duration_rules.(*validate.DurationRules)
+	var rules []namedRule[Duration]
+
+	if r.Const != nil {
+		rules = append(rules, newRule("duration.const", *ruleDuration(r.Const), DurationConst(*ruleDuration(r.Const))))
+	}
+	rules = addDurationRangeRule(r, rules)
+	if len(r.In) > 0 {
+		rules = append(rules, newRule("duration.in", ruleDurations(r.In), DurationIn(ruleDurations(r.In))))
+	}
+	if len(r.NotIn) > 0 {
+		rules = append(rules, newRule("duration.not_in", ruleDurations(r.NotIn), DurationNotIn(ruleDurations(r.NotIn))))
+	}
+	return rules
+}
+
+func parseAny(any_rules protoreflect.ProtoMessage) []namedRule[string] {
+	r := any_rules.(*validate.AnyRules)
+	var rules []namedRule[string]
+
+	if len(r.In) > 0 {
+		rules = append(rules, newRule("any.in", r.In, AnyIn(r.In)))
+	}
+	if len(r.NotIn) > 0 {
+		rules = append(rules, newRule("any.not_in", r.NotIn, AnyNotIn(r.NotIn)))
+	}
+	return rules
+}
+
+// map字段的键值对个数
+func parseMap(map_rules protoreflect.ProtoMessage) []namedRule[map[string]any] {
+	val := getValue(map_rules)
+	var rules []namedRule[map[string]any]
+
+	rules = addRule[map[string]any, uint64]("MinPairs", MapMinPairs)(val, rules)
+	rules = addRule[map[string]any, uint64]("MaxPairs", MapMaxPairs)(val, rules)
+	return rules
+}
+
+// repeated字段的元素个数，unique的错误信息需要字段的路径，在校验时添加
+func parseRepeated(repeated_rules protoreflect.ProtoMessage) []namedRule[[]any] {
+	val := getValue(repeated_rules)
+	var rules []namedRule[[]any]
+
+	rules = addRule[[]any, uint64]("MinItems", RepeatedMinItems)(val, rules)
+	rules = addRule[[]any, uint64]("MaxItems", RepeatedMaxItems)(val, rules)
+	return rules
+}
+
+func parseEnum(enum_rules protoreflect.ProtoMessage, enum_values_number []int32) []namedRule[int32] {
+	val := getValue(enum_rules)
+	var rules []namedRule[int32]
+
+	rules = addRule[int32, int32]("Const", ScalarConst)(val, rules)
+	rules = addDefinedOnlyRule(enum_values_number, rules)
+	rules = addInRule(val, rules)
+	rules = addNotInRule(val, rules)
+	return rules
+}
+
+// 将数据转换为字段类型的值，枚举需要根据枚举的定义解析名称，字节串需要按照编码解码，Struct等任意JSON值原样返回
+func convertValue(typ string, enum pgs.Enum, raw any, opts *Options) (any, error) {
+	switch typ {
+	case "enum":
+		return toEnum(raw, enum, opts.EnumFormat, opts.Lenient)
+	case "bytes":
+		return toBytes(raw, opts.BytesEncoding)
+	case "json":
+		return raw, nil
+	}
+	return typeConvertFuncMap[typ](raw, opts.Lenient)
+}
+
+// 值是否为零值，空字节串也视为零值
+func isZeroValue(v any) bool {
+	if b, ok := v.([]byte); ok {
+		return len(b) == 0
+	}
+	return reflect.ValueOf(v).IsZero()
+}
+
+func getValue(numberRules protoreflect.ProtoMessage) reflect.Value {
+	val := reflect.ValueOf(numberRules)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	return val
+}
+
+// 返回一堆验证函数
+func parseNumber[T Number](numberRules protoreflect.ProtoMessage) []namedRule[T] {
+	val := getValue(numberRules)
+	var rules []namedRule[T]
+
+	rules = addRule[T, T]("Const", ScalarConst)(val, rules)
+	rules = addRangeRule(val, rules)
+	rules = addInRule(val, rules)
+	rules = addNotInRule(val, rules)
+
+	return rules
+}
+
+// 验证value是否满足规则，每个不通过的规则对应一条错误
+func validateRules[T any](val T, rules []namedRule[T], tr *FieldTrace) (result ValidationResult) {
+	for _, rule := range rules {
+		tr.rule(rule.Id, rule.Param)
+		if ok, m := rule.Check(val); !ok {
+			result = append(result, Violation{Rule: rule.Id, Param: rule.Param, Value: val, Message: m})
+		}
+	}
+	return
+}
+
+func rulesContext(f pgs.Field) (out shared.RuleContext, err error) {
+	out.Field = f
+
+	var rules validate.FieldRules
+	if _, err = f.Extension(validate.E_Rules, &rules); err != nil {
+		return
+	}
+
+	var wrapped bool
+	if out.Typ, out.Rules, out.MessageRules, wrapped = resolveRules(f.Type(), &rules); wrapped {
+		out.WrapperTyp = out.Typ
+		out.Typ = "wrapper"
+	}
+
+	if out.Typ == "error" {
+		err = fmt.Errorf("unknown rule type (%T)", rules.Type)
+	}
+
+	return
+}
+
+// 字段类型或repeated字段的元素类型
+type ruleFieldType interface {
+	IsEmbed() bool
+	Embed() pgs.Message
+	ProtoType() pgs.ProtoType
+}
+
+// 包装类型内部的标量类型
+var wrapperTypes = map[pgs.WellKnownType]pgs.ProtoType{
+	pgs.DoubleValueWKT: pgs.DoubleT,
+	pgs.FloatValueWKT:  pgs.FloatT,
+	pgs.Int64ValueWKT:  pgs.Int64T,
+	pgs.UInt64ValueWKT: pgs.UInt64T,
+	pgs.Int32ValueWKT:  pgs.Int32T,
+	pgs.UInt32ValueWKT: pgs.UInt32T,
+	pgs.BoolValueWKT:   pgs.BoolT,
+	pgs.StringValueWKT: pgs.StringT,
+	pgs.BytesValueWKT:  pgs.BytesT,
+}
+
+func resolveRules(typ ruleFieldType, rules *validate.FieldRules) (ruleType string, rule proto.Message, messageRule *validate.MessageRules, wrapped bool) {
+	if ft, ok := typ.(pgs.FieldType); ok && ft.IsRepeated() {
+		return "repeated", rules.GetRepeated(), rules.GetMessage(), false
+	} else if ok && ft.IsMap() {
+		return "map", rules.GetMap(), rules.GetMessage(), false
+	}
+
+	proto_type := typ.ProtoType()
+	if typ.IsEmbed() && isJSONValue(typ.Embed()) {
+		return "json", rules.GetMessage(), rules.GetMessage(), false
+	}
+	if typ.IsEmbed() {
+		switch wkt := typ.Embed().WellKnownType(); wkt {
+		case pgs.AnyWKT:
+			return "any", rules.GetAny(), rules.GetMessage(), false
+		case pgs.DurationWKT:
+			return "duration", rules.GetDuration(), rules.GetMessage(), false
+		case pgs.TimestampWKT:
+			return "timestamp", rules.GetTimestamp(), rules.GetMessage(), false
+		default:
+			// 包装类型按照内部的标量类型获取规则，wrapped为true
+			inner, ok := wrapperTypes[wkt]
+			if !ok {
+				return "message", rules.GetMessage(), rules.GetMessage(), false
+			}
+			proto_type = inner
+		}
+	}
+
+	switch proto_type {
+	case pgs.FloatT:
+		ruleType, rule, wrapped = "float", rules.GetFloat(), typ.IsEmbed()
+	case pgs.DoubleT:
+		ruleType, rule, wrapped = "double", rules.GetDouble(), typ.IsEmbed()
+	case pgs.Int32T:
+		ruleType, rule, wrapped = "int32", rules.GetInt32(), typ.IsEmbed()
+	case pgs.Int64T:
+		ruleType, rule, wrapped = "int64", rules.GetInt64(), typ.IsEmbed()
+	case pgs.UInt32T:
+		ruleType, rule, wrapped = "uint32", rules.GetUint32(), typ.IsEmbed()
+	case pgs.UInt64T:
+		ruleType, rule, wrapped = "uint64", rules.GetUint64(), typ.IsEmbed()
+	case pgs.SInt32:
+		ruleType, rule, wrapped = "sint32", rules.GetSint32(), false
+	case pgs.SInt64:
+		ruleType, rule, wrapped = "sint64", rules.GetSint64(), false
+	case pgs.Fixed32T:
+		ruleType, rule, wrapped = "fixed32", rules.GetFixed32(), false
+	case pgs.Fixed64T:
+		ruleType, rule, wrapped = "fixed64", rules.GetFixed64(), false
+	case pgs.SFixed32:
+		ruleType, rule, wrapped = "sfixed32", rules.GetSfixed32(), false
+	case pgs.SFixed64:
+		ruleType, rule, wrapped = "sfixed64", rules.GetSfixed64(), false
+	case pgs.BoolT:
+		ruleType, rule, wrapped = "bool", rules.GetBool(), typ.IsEmbed()
+	case pgs.StringT:
+		ruleType, rule, wrapped = "string", rules.GetString_(), typ.IsEmbed()
+	case pgs.BytesT:
+		ruleType, rule, wrapped = "bytes", rules.GetBytes(), typ.IsEmbed()
+	case pgs.EnumT:
+		ruleType, rule, wrapped = "enum", rules.GetEnum(), false
+	// case nil:
+	// 	if ft, ok := typ.(pgs.FieldType); ok && ft.IsRepeated() {
+	// 		return "repeated", &validate.RepeatedRules{}, rules.Message, false
+	// 	} else if ok && ft.IsMap() && ft.Element().IsEmbed() {
+	// 		return "map", &validate.MapRules{}, rules.Message, false
+	// 	} else if typ.IsEmbed() {
+	// 		return "message", rules.GetMessage(), rules.GetMessage(), false
+	// 	}
+	// 	return "none", nil, nil, false
+	default:
+		ruleType, rule, wrapped = "error", nil, false
+	}
+
+	return ruleType, rule, rules.GetMessage(), wrapped
+}
+
+// add Rules
+
+// var addRulesFuncMap map[string]func(reflect.Value, []Rule[any]) []Rule[any] = make(map[string]func(reflect.Value, []Rule[any]) []Rule[any])
+
+// func init() {
+// 	addRulesFuncMap["Const"] =
+// }
+
+var camelCasePattern = regexp.MustCompile("([a-z0-9])([A-Z])")
+
+// AbcDef -> abc_def
+func camelCaseToSnakeCase(s string) string {
+	snake := camelCasePattern.ReplaceAllString(s, "${1}_${2}")
+	return strings.ToLower(snake)
+}
+
+// 添加"校验规则函数"的函数，T代表校验的类型
+type addRuleFunc[T any] func(reflect.Value, []namedRule[T]) []namedRule[T]
+
+// addRule("Const", ScalarConst(constVal)) -> addRuleFunc[T]
+// T: 校验类型
+// V: 字段类型
+func addRule[T any, V any](name string, rule_func_getter ruleFuncGetter[T, V]) addRuleFunc[T] {
+	sname := camelCaseToSnakeCase(name) // 规则id的后半部分
+	return func(rval reflect.Value, rules []namedRule[T]) []namedRule[T] {
+		ok, val := getFieldPointer[V](rval, name)
+		if ok {
+			return append(rules, newRule(ruleIdPrefix(rval)+"."+sname, val, rule_func_getter(val)))
+		}
+		return rules
+	}
+}
+
+// 规则字段为切片（如 bytes 的 Const、In）时使用，V为切片的元素类型
+func addSliceRule[T any, V any](name string, rule_func_getter ruleFuncGetter[T, []V]) addRuleFunc[T] {
+	sname := camelCaseToSnakeCase(name) // 规则id的后半部分
+	return func(rval reflect.Value, rules []namedRule[T]) []namedRule[T] {
+		ok, val := getFieldArray[V](rval, name)
+		if ok {
+			return append(rules, newRule(ruleIdPrefix(rval)+"."+sname, val, rule_func_getter(val)))
+		}
+		return rules
+	}
+}
+
+// pattern规则，正则表达式无法编译时返回错误
+func addPatternRule[T any](rule_func func(string) (RuleFunc[T], error)) func(reflect.Value, []namedRule[T]) ([]namedRule[T], error) {
+	return func(rval reflect.Value, rules []namedRule[T]) ([]namedRule[T], error) {
+		ok, pattern := getFieldPointer[string](rval, "Pattern")
+		if !ok {
+			return rules, nil
+		}
+		check, err := rule_func(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return append(rules, newRule(ruleIdPrefix(rval)+".pattern", pattern, check)), nil
+	}
+}
+
+// well_known 中的格式规则（如 ip、email），选项为true时才校验
+func addWellKnownRule[T any](name string, rule_func func() RuleFunc[T]) addRuleFunc[T] {
+	sname := camelCaseToSnakeCase(name) // 规则id的后半部分
+	return func(rval reflect.Value, rules []namedRule[T]) []namedRule[T] {
+		ok, val := getOneofBool(rval, "WellKnown", name)
+		if ok && val {
+			return append(rules, newRule(ruleIdPrefix(rval)+"."+sname, val, rule_func()))
+		}
+		return rules
+	}
+}
+
+// lt、lte、gt、gte 合并为一个范围规则
+func addRangeRule[T Number](val reflect.Value, rules []namedRule[T]) []namedRule[T] {
+	bounds := make(map[string]*T)
+	for _, name := range []string{"Lt", "Lte", "Gt", "Gte"} {
+		if ok, bound := getFieldPointer[T](val, name); ok {
+			bounds[name] = &bound
+		}
+	}
+	if rule := NumberRange(bounds["Lt"], bounds["Lte"], bounds["Gt"], bounds["Gte"]); rule != nil {
+		rules = append(rules, rangeRule(ruleIdPrefix(val), bounds["Lt"], bounds["Lte"], bounds["Gt"], bounds["Gte"], rule))
+	}
+	return rules
+}
+
+// 范围规则的id由设置的边界组成，下界在前，如 int32.gt_lt。只有一个边界时参数为边界值
+func rangeRule[T any](prefix string, lt, lte, gt, gte *T, check RuleFunc[T]) namedRule[T] {
+	var names []string
+	bounds := make(map[string]any)
+	for _, b := range []struct {
+		name  string
+		bound *T
+	}{{"gt", gt}, {"gte", gte}, {"lt", lt}, {"lte", lte}} {
+		if b.bound != nil {
+			names = append(names, b.name)
+			bounds[b.name] = *b.bound
+		}
+	}
+	var param any = bounds
+	if len(names) == 1 {
+		param = bounds[names[0]]
+	}
+	return newRule(prefix+"."+strings.Join(names, "_"), param, check)
+}
+
+func addTimestampRangeRule(r *validate.TimestampRules, rules []namedRule[Timestamp]) []namedRule[Timestamp] {
+	bounds := map[string]*Timestamp{
+		"lt":  ruleTimestamp(r.Lt),
+		"lte": ruleTimestamp(r.Lte),
+		"gt":  ruleTimestamp(r.Gt),
+		"gte": ruleTimestamp(r.Gte),
+	}
+	if rule := TimestampRange(bounds["lt"], bounds["lte"], bounds["gt"], bounds["gte"]); rule != nil {
+		rules = append(rules, rangeRule("timestamp", bounds["lt"], bounds["lte"], bounds["gt"], bounds["gte"], rule))
+	}
+	return rules
+}
+
+func addDurationRangeRule(r *validate.DurationRules, rules []namedRule[Duration]) []namedRule[Duration] {
+	bounds := map[string]*Duration{
+		"lt":  ruleDuration(r.Lt),
+		"lte": ruleDuration(r.Lte),
+		"gt":  ruleDuration(r.Gt),
+		"gte": ruleDuration(r.Gte),
+	}
+	if rule := DurationRange(bounds["lt"], bounds["lte"], bounds["gt"], bounds["gte"]); rule != nil {
+		rules = append(rules, rangeRule("duration", bounds["lt"], bounds["lte"], bounds["gt"], bounds["gte"], rule))
+	}
+	return rules
+}
+
+// 规则中未设置的时间、时长为nil
+func ruleTimestamp(ts *timestamppb.Timestamp) *Timestamp {
+	if ts == nil {
+		return nil
+	}
+	return &Timestamp{ts.AsTime()}
+}
+
+func ruleDuration(d *durationpb.Duration) *Duration {
+	if d == nil {
+		return nil
+	}
+	return &Duration{Seconds: d.GetSeconds(), Nanos: d.GetNanos()}
+}
+
+func ruleDurations(ds []*durationpb.Duration) []Duration {
+	vals := make([]Duration, 0, len(ds))
+	for _, d := range ds {
+		vals = append(vals, *ruleDuration(d))
+	}
+	return vals
+}
+
+func addInRule[T Number | string](val reflect.Value, rules []namedRule[T]) []namedRule[T] {
+	ok, in := getFieldArray[T](val, "In")
+	if ok {
+		return append(rules, newRule(ruleIdPrefix(val)+".in", in, ScalarIn(in)))
+	}
+	return rules
+}
+
+func addNotInRule[T Number | string](val reflect.Value, rules []namedRule[T]) []namedRule[T] {
+	ok, not_in := getFieldArray[T](val, "NotIn")
+	if ok {
+		rules = append(rules, newRule(ruleIdPrefix(val)+".not_in", not_in, ScalarNotIn(not_in)))
+	}
+	return rules
+}
+
+// well_known_regex，strict默认为true
+func addWellKnownRegexRule(string_rules *validate.StringRules, rules []namedRule[string]) []namedRule[string] {
+	known := string_rules.GetWellKnownRegex()
+	if known == validate.KnownRegex_UNKNOWN {
+		return rules
+	}
+	return append(rules, newRule("string.well_known_regex", known.String(), StringWellKnownRegex(known, string_rules.GetStrict())))
+}
+
+// 枚举的有效值在编译规则时捕获，不依赖全局状态
+func addDefinedOnlyRule(enum_values_number []int32, rules []namedRule[int32]) []namedRule[int32] {
+	rules = append(rules, newRule("enum.defined_only", true, EnumDefinedOnly(enum_values_number)))
+	return rules
+}
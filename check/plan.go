@@ -0,0 +1,639 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/envoyproxy/protoc-gen-validate/validate"
+	pgs "github.com/lyft/protoc-gen-star/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+/*
+*
+
+	消息的校验计划
+	1. 字段的校验规则、存在性、数据中的键只在编译时解析一次
+	2. 规则编译为带类型的 []namedRule[T]，正则表达式、枚举值集合等在编译时准备好，规则有误时编译失败
+	3. 校验数据时只执行计划。计划编译后只读，可以在多个goroutine中同时使用
+*/
+type messagePlan struct {
+	message  pgs.Message
+	disabled bool            // (validate.disabled) 或 (validate.ignored)
+	known    map[string]bool // 严格模式：字段在数据中可用的键
+	names    []string        // 严格模式：用于提示拼写错误的键
+	oneofs   []*oneofPlan
+	fields   []*fieldPlan
+}
+
+// 编译消息的校验计划，md 为同一个消息的protoreflect描述符，用于解析字段的存在性。
+// cache 记录已编译的消息，嵌套消息引用自身时复用同一个计划。
+// 编译失败时 cache 中可能留有不完整的计划，不能再使用
+func compileMessage(m pgs.Message, md protoreflect.MessageDescriptor, cache map[string]*messagePlan) (*messagePlan, error) {
+	if p, ok := cache[m.FullyQualifiedName()]; ok {
+		return p, nil
+	}
+	p := &messagePlan{message: m, disabled: messageDisabled(m), known: make(map[string]bool)}
+	cache[m.FullyQualifiedName()] = p
+	if p.disabled {
+		return p, nil
+	}
+
+	fields := make(map[string]*fieldPlan)
+	for _, f := range m.Fields() {
+		fd := md.Fields().ByNumber(protoreflect.FieldNumber(f.Descriptor().GetNumber()))
+		if fd == nil {
+			return nil, fmt.Errorf("消息 %s 的描述符中没有字段 %s", md.FullName(), f.Name())
+		}
+		fp, err := compileField(f, fd, cache)
+		if err != nil {
+			return nil, err
+		}
+		fields[f.FullyQualifiedName()] = fp
+		p.fields = append(p.fields, fp)
+		for _, key := range fp.keys {
+			p.known[key] = true
+			p.names = append(p.names, key)
+		}
+	}
+	for _, o := range m.RealOneOfs() {
+		op, err := compileOneOf(o, fields)
+		if err != nil {
+			return nil, err
+		}
+		p.oneofs = append(p.oneofs, op)
+	}
+	return p, nil
+}
+
+/*
+*
+
+	按照计划校验消息
+	1. 严格模式下，数据中不能有未知字段
+	2. oneof最多只能设置一个字段
+	3. 逐个校验消息的字段
+	4. 嵌套消息字段按照JSON对象递归校验，path为字段所在的路径
+*/
+func (p *messagePlan) check(rawData map[string]any, path string, opts *Options) (result ValidationResult) {
+	if p.disabled {
+		return
+	}
+
+	if opts.Strict {
+		result = append(result, checkUnknownKeys(p, rawData, path)...)
+	}
+
+	for _, o := range p.oneofs {
+		result = append(result, o.check(rawData, path+o.name)...)
+	}
+
+	for _, f := range p.fields {
+		name := path + f.name
+		result = append(result, f.check(rawData, name, opts)...)
+
+		// 嵌套消息，递归校验
+		if sub, ok, _ := f.lookup(rawData); ok && f.embed != nil {
+			result = append(result, f.checkEmbedded(sub, name, opts)...)
+		}
+	}
+	return
+}
+
+// oneof的校验计划
+type oneofPlan struct {
+	name     string
+	fqn      string
+	members  []*fieldPlan
+	required bool // (validate.required)
+}
+
+func compileOneOf(o pgs.OneOf, fields map[string]*fieldPlan) (*oneofPlan, error) {
+	p := &oneofPlan{name: o.Name().String(), fqn: strings.TrimPrefix(o.FullyQualifiedName(), ".")}
+	for _, f := range o.Fields() {
+		p.members = append(p.members, fields[f.FullyQualifiedName()])
+	}
+	if _, err := o.Extension(validate.E_Required, &p.required); err != nil {
+		return nil, fmt.Errorf("oneof %s 的选项无法读取: %w", p.fqn, err)
+	}
+	return p, nil
+}
+
+/*
+*
+
+	检测oneof是否复合规范
+	1. 最多只能设置一个字段
+	2. 设置了 (validate.required) 时必须设置其中一个字段
+*/
+func (p *oneofPlan) check(rawData map[string]any, path string) (result ValidationResult) {
+	var set, members []string
+	for _, f := range p.members {
+		members = append(members, f.name)
+		if _, ok, _ := f.lookup(rawData); ok {
+			set = append(set, f.name)
+		}
+	}
+
+	switch {
+	case len(set) > 1:
+		result = append(result, newViolation("oneof", set, fmt.Sprintf("oneof %s 只能设置一个字段，但同时设置了: %s", path, strings.Join(set, ", "))))
+	case len(set) == 0 && p.required:
+		result = append(result, newViolation("oneof.required", nil, fmt.Sprintf("oneof %s 是必须的，需要设置其中一个字段: %s", path, strings.Join(members, ", "))))
+	}
+	for i := range result {
+		result[i].Field = p.fqn
+	}
+	return result.at(path)
+}
+
+// 字段的校验计划，repeated、map和单个值分别使用对应的计划
+type fieldPlan struct {
+	field    pgs.Field
+	name     string                                // 字段名
+	fqn      string                                // 字段的全限定名，不含开头的点
+	typ      string                                // 字段的proto类型，用于调试信息
+	keys     []string                              // 字段在数据中可用的键
+	presence descriptorpb.FeatureSet_FieldPresence // 字段的存在性
+	required string                                // 必填规则的id，非必填时为空
+
+	value    *valuePlan
+	repeated *repeatedPlan
+	mapping  *mapPlan
+	embed    *messagePlan // 需要递归校验的嵌套消息，message.skip 或WKT时为nil
+}
+
+func compileField(f pgs.Field, fd protoreflect.FieldDescriptor, cache map[string]*messagePlan) (*fieldPlan, error) {
+	p := &fieldPlan{
+		field:    f,
+		name:     f.Name().String(),
+		fqn:      strings.TrimPrefix(f.FullyQualifiedName(), "."),
+		typ:      f.Type().ProtoType().String(),
+		keys:     fieldKeys(f),
+		presence: fieldPresence(fd),
+	}
+
+	ruleContext, err := rulesContext(f)
+	if err != nil {
+		return nil, fmt.Errorf("字段 %s 的规则无法解析: %w", p.fqn, err)
+	}
+
+	// proto2的required，或者规则中的required
+	p.required = ruleRequired(f, ruleContext)
+	if p.presence == descriptorpb.FeatureSet_LEGACY_REQUIRED {
+		p.required = "required"
+	}
+
+	switch ruleContext.Typ {
+	case "repeated":
+		p.repeated, err = compileRepeated(f.Type().Element(), ruleContext.Rules.(*validate.RepeatedRules))
+	case "map":
+		p.mapping, err = compileMap(f.Type().Key(), f.Type().Element(), ruleContext.Rules.(*validate.MapRules))
+	case "wrapper":
+		// 包装类型在JSON中直接使用内部的标量值，用字段的规则校验内部的值
+		p.value, err = compileValue(ruleContext.WrapperTyp, nil, ruleContext.Rules)
+	default:
+		p.value, err = compileValue(ruleContext.Typ, f.Type().Enum(), ruleContext.Rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("字段 %s 的规则有误: %w", p.fqn, err)
+	}
+
+	if !skipEmbedded(ruleContext) {
+		switch {
+		case f.Type().IsMap() && isPlainEmbed(f.Type().Element()):
+			p.embed, err = compileMessage(f.Type().Element().Embed(), fd.MapValue().Message(), cache)
+		case f.Type().IsRepeated() && isPlainEmbed(f.Type().Element()):
+			p.embed, err = compileMessage(f.Type().Element().Embed(), fd.Message(), cache)
+		case isPlainEmbed(f.Type()):
+			p.embed, err = compileMessage(f.Type().Embed(), fd.Message(), cache)
+		}
+	}
+	return p, err
+}
+
+/*
+*
+
+	检测字段是否复合规范
+	1. 若字段是必须的，是否已经设置
+	2. 字段的类型是否一致
+	3. 字段是否符合校验规则
+*/
+func (p *fieldPlan) check(rawData map[string]any, path string, opts *Options) (result ValidationResult) {
+	// 只有需要输出调试信息时才记录校验过程
+	var tr *FieldTrace
+	if opts.Trace != nil {
+		tr = newFieldTrace(path, p.typ)
+	}
+
+	// 错误补充路径和字段名，并输出调试信息
+	defer func() {
+		result = result.at(path)
+		for i := range result {
+			result[i].Field = p.fqn
+		}
+		if tr != nil {
+			opts.Trace(tr)
+		}
+	}()
+
+	// 未设置的可选字段使用声明的默认值
+	if opts.ApplyDefaults {
+		if err := p.applyDefault(rawData, opts); err != nil {
+			return ValidationResult{newViolation("default", p.field.Descriptor().GetDefaultValue(), err.Error())}
+		}
+	}
+
+	// 检验必要字段是否已经设置
+	raw, ok, err := p.lookup(rawData)
+	if err != nil {
+		return ValidationResult{newViolation("duplicate", nil, err.Error())}
+	}
+	if p.required != "" {
+		if tr != nil {
+			tr.Required = true
+		}
+		if !ok {
+			return ValidationResult{newViolation(p.required, nil, fmt.Sprintf("字段 %s 是必须的", path))}
+		}
+	} else if !ok && p.presence != descriptorpb.FeatureSet_IMPLICIT {
+		// 可选字段不存在，跳过。隐式存在的字段未设置时按零值校验
+		return
+	}
+	if !ok {
+		raw = zeroValue(p.field, opts)
+	}
+
+	// 检验字段类型和校验信息
+	switch {
+	case p.repeated != nil:
+		return p.repeated.check(raw, path, opts, tr)
+	case p.mapping != nil:
+		return p.mapping.check(raw, path, opts, tr)
+	}
+	return p.value.check(raw, opts, tr)
+}
+
+// 获取字段在数据中的值，null视为未设置。
+// 同一个字段的多种写法同时出现时返回错误
+func (p *fieldPlan) lookup(rawData map[string]any) (val any, ok bool, err error) {
+	found := ""
+	for _, key := range p.keys {
+		v, exists := rawData[key]
+		if !exists {
+			continue
+		}
+		if found != "" {
+			return nil, false, fmt.Errorf("字段 %s 重复出现: %s 与 %s", p.name, found, key)
+		}
+		found = key
+		val, ok = v, v != nil
+	}
+	return
+}
+
+// 显式存在的字段未设置且声明了默认值时，将默认值写入数据，之后按照已设置的字段校验
+func (p *fieldPlan) applyDefault(rawData map[string]any, opts *Options) error {
+	def := p.field.Descriptor().DefaultValue
+	if def == nil || p.presence != descriptorpb.FeatureSet_EXPLICIT {
+		return nil
+	}
+	if _, ok, err := p.lookup(rawData); ok || err != nil {
+		return nil
+	}
+
+	val, err := defaultValue(p.field, *def, opts)
+	if err != nil {
+		return fmt.Errorf("字段 %s 的默认值 %q 无法解析: %w", p.name, *def, err)
+	}
+	// 值为null的其它写法一并移除，避免与默认值重复
+	for _, key := range p.keys {
+		delete(rawData, key)
+	}
+	rawData[p.name] = val
+	return nil
+}
+
+// 递归校验嵌套消息，repeated和map字段逐个元素校验。类型不匹配的值已在check中报告，这里跳过
+func (p *fieldPlan) checkEmbedded(val any, path string, opts *Options) (result ValidationResult) {
+	switch {
+	case p.repeated != nil:
+		items, _ := val.([]any)
+		for i, item := range items {
+			if sub, ok := item.(map[string]any); ok {
+				result = append(result, p.embed.check(sub, fmt.Sprintf("%s[%d].", path, i), opts)...)
+			}
+		}
+	case p.mapping != nil:
+		pairs, _ := val.(map[string]any)
+		for _, k := range sortedKeys(pairs) {
+			if sub, ok := pairs[k].(map[string]any); ok {
+				result = append(result, p.embed.check(sub, fmt.Sprintf("%s[%q].", path, k), opts)...)
+			}
+		}
+	default:
+		if sub, ok := val.(map[string]any); ok {
+			result = append(result, p.embed.check(sub, path+".", opts)...)
+		}
+	}
+	return
+}
+
+func sortedKeys(pairs map[string]any) []string {
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// 单个值的校验计划，用于普通字段以及repeated的元素、map的键和值
+type valuePlan struct {
+	typ         string
+	enum        pgs.Enum
+	ignoreEmpty bool
+	validate    func(val any, tr *FieldTrace) ValidationResult // 没有校验规则时为nil，只校验类型
+}
+
+func compileValue(typ string, enum pgs.Enum, typ_rules proto.Message) (*valuePlan, error) {
+	p := &valuePlan{typ: typ, enum: enum}
+	// https://www.cnblogs.com/mfrank/p/16831877.html 不能直接写成Nil比较
+	if typ == "message" || typ == "json" || reflect.ValueOf(typ_rules).IsNil() {
+		return p, nil
+	}
+	ok, ignore_empty := getBool(getValue(typ_rules), "IgnoreEmpty")
+	p.ignoreEmpty = ok && ignore_empty
+
+	switch typ {
+	case "uint32", "fixed32":
+		p.validate = ruleValidator(parseNumber[uint32](typ_rules))
+	case "uint64", "fixed64":
+		p.validate = ruleValidator(parseNumber[uint64](typ_rules))
+	case "int32", "sint32", "sfixed32":
+		p.validate = ruleValidator(parseNumber[int32](typ_rules))
+	case "int64", "sint64", "sfixed64":
+		p.validate = ruleValidator(parseNumber[int64](typ_rules))
+	case "double":
+		p.validate = ruleValidator(parseNumber[float64](typ_rules))
+	case "float":
+		p.validate = ruleValidator(parseNumber[float32](typ_rules))
+	case "bool":
+		p.validate = ruleValidator(parseBool(typ_rules))
+	case "string":
+		rules, err := parseString(typ_rules)
+		if err != nil {
+			return nil, err
+		}
+		p.validate = ruleValidator(rules)
+	case "bytes":
+		rules, err := parseBytes(typ_rules)
+		if err != nil {
+			return nil, err
+		}
+		p.validate = ruleValidator(rules)
+	case "enum":
+		var enum_values_number []int32
+		for _, enum_value := range enum.Values() {
+			enum_values_number = append(enum_values_number, enum_value.Value())
+		}
+		p.validate = ruleValidator(parseEnum(typ_rules, enum_values_number))
+	case "timestamp":
+		p.validate = ruleValidator(parseTimestamp(typ_rules))
+	case "duration":
+		p.validate = ruleValidator(parseDuration(typ_rules))
+	case "any":
+		p.validate = ruleValidator(parseAny(typ_rules))
+	default:
+		return nil, fmt.Errorf("不支持类型 %s", typ)
+	}
+	return p, nil
+}
+
+// 将编译好的规则包装为校验函数，值已经转换为规则的类型T
+func ruleValidator[T any](rules []namedRule[T]) func(any, *FieldTrace) ValidationResult {
+	return func(val any, tr *FieldTrace) ValidationResult {
+		return validateRules(val.(T), rules, tr)
+	}
+}
+
+/*
+*
+
+	校验单个值
+	1. 值的类型是否与字段类型一致
+	2. 值是否符合校验规则，错误中的值为数据中的原始值
+*/
+func (p *valuePlan) check(raw any, opts *Options, tr *FieldTrace) (result ValidationResult) {
+	// 嵌套消息只校验是否为JSON对象，字段由嵌套消息的计划递归校验
+	if p.typ == "message" {
+		if _, ok := raw.(map[string]any); !ok {
+			result = append(result, newViolation("type", raw, typeMismatch("object", raw).Error()))
+		}
+		tr.value("{...}")
+		return
+	}
+	tr.value(raw)
+
+	// 无validate校验，但是仍需要校验类型
+	if p.validate == nil {
+		if _, err := convertValue(p.typ, p.enum, raw, opts); err != nil {
+			result = append(result, typeViolation(raw, err))
+		}
+		return
+	}
+
+	// ignore_empty: 空字符串直接跳过
+	if p.ignoreEmpty && raw == "" {
+		tr.ignoreEmpty()
+		return
+	}
+
+	// 校验类型
+	value_any, err := convertValue(p.typ, p.enum, raw, opts)
+	if err != nil {
+		result = append(result, typeViolation(raw, err))
+		return
+	}
+
+	// ignore_empty: 零值跳过
+	if p.ignoreEmpty && isZeroValue(value_any) {
+		tr.ignoreEmpty()
+		return
+	}
+
+	result = p.validate(value_any, tr)
+	for i := range result {
+		result[i].Value = raw
+	}
+	return
+}
+
+// 值无法转换为字段类型。字符串不是合法的UTF-8时单独报告为 string.utf8
+func typeViolation(raw any, err error) Violation {
+	if errors.Is(err, errInvalidUTF8) {
+		return newViolation("string.utf8", raw, err.Error())
+	}
+	return newViolation("type", raw, err.Error())
+}
+
+// repeated字段的校验计划
+type repeatedPlan struct {
+	hasRules    bool // 是否设置了repeated规则
+	ignoreEmpty bool
+	items       *valuePlan
+	rules       []namedRule[[]any] // min_items、max_items
+	unique      bool
+}
+
+func compileRepeated(elem pgs.FieldTypeElem, repeated_rules *validate.RepeatedRules) (*repeatedPlan, error) {
+	p := &repeatedPlan{hasRules: repeated_rules != nil, ignoreEmpty: repeated_rules.GetIgnoreEmpty()}
+
+	typ, item_rules, _, _ := resolveRules(elem, repeated_rules.GetItems())
+	if typ == "error" {
+		return nil, fmt.Errorf("unknown rule type (%T)", repeated_rules.GetItems().GetType())
+	}
+	var err error
+	if p.items, err = compileValue(typ, elem.Enum(), item_rules); err != nil {
+		return nil, fmt.Errorf("items: %w", err)
+	}
+
+	if p.hasRules {
+		p.rules = parseRepeated(repeated_rules)
+		p.unique = repeated_rules.GetUnique()
+	}
+	return p, nil
+}
+
+/*
+*
+
+	处理repeated字段
+	1. 数据必须是JSON数组，用items规则逐个校验元素，错误指向具体的元素，如 tags[3]
+	2. 再校验元素个数 min_items、max_items 以及 unique
+*/
+func (p *repeatedPlan) check(raw any, path string, opts *Options, tr *FieldTrace) (result ValidationResult) {
+	items, ok := raw.([]any)
+	if !ok {
+		return ValidationResult{newViolation("type", raw, typeMismatch("array", raw).Error())}
+	}
+	if p.ignoreEmpty && len(items) == 0 {
+		tr.ignoreEmpty()
+		return
+	}
+
+	item_tr := tr.elem()
+	values := make([]any, len(items))
+	for i, item := range items {
+		name := fmt.Sprintf("%s[%d]", path, i)
+		if item_result := p.items.check(item, opts, item_tr); !item_result.Valid() {
+			result = append(result, item_result.at(name)...)
+			continue
+		}
+		if p.items.typ != "message" {
+			values[i], _ = convertValue(p.items.typ, p.items.enum, item, opts)
+		}
+	}
+	tr.value(raw)
+
+	if !p.hasRules {
+		return
+	}
+
+	// unique的错误信息包含字段的路径，校验时才能确定。复制规则，不修改计划
+	rules := p.rules
+	if p.unique {
+		rules = append(rules[:len(rules):len(rules)], newRule("repeated.unique", true, RepeatedUnique(path)))
+	}
+	repeated_result := validateRules(values, rules, tr)
+	for i := range repeated_result {
+		repeated_result[i].Value = raw
+	}
+	return append(result, repeated_result...)
+}
+
+// map字段的校验计划
+type mapPlan struct {
+	hasRules    bool // 是否设置了map规则
+	ignoreEmpty bool
+	noSparse    bool
+	keys        *valuePlan
+	values      *valuePlan
+	rules       []namedRule[map[string]any] // min_pairs、max_pairs
+}
+
+func compileMap(key pgs.FieldTypeElem, elem pgs.FieldTypeElem, map_rules *validate.MapRules) (*mapPlan, error) {
+	p := &mapPlan{hasRules: map_rules != nil, ignoreEmpty: map_rules.GetIgnoreEmpty(), noSparse: map_rules.GetNoSparse()}
+
+	key_typ, key_rules, _, _ := resolveRules(key, map_rules.GetKeys())
+	val_typ, val_rules, _, _ := resolveRules(elem, map_rules.GetValues())
+	if key_typ == "error" || val_typ == "error" {
+		return nil, fmt.Errorf("unknown rule type (%T, %T)", map_rules.GetKeys().GetType(), map_rules.GetValues().GetType())
+	}
+	var err error
+	if p.keys, err = compileValue(key_typ, key.Enum(), key_rules); err != nil {
+		return nil, fmt.Errorf("keys: %w", err)
+	}
+	if p.values, err = compileValue(val_typ, elem.Enum(), val_rules); err != nil {
+		return nil, fmt.Errorf("values: %w", err)
+	}
+
+	if p.hasRules {
+		p.rules = parseMap(map_rules)
+	}
+	return p, nil
+}
+
+/*
+*
+
+	处理map字段
+	1. 数据必须是JSON对象，键按照键类型转换后用keys规则校验，值用values规则校验，
+	   错误指向具体的键值对，如 labels["foo"]
+	2. 值为消息类型时，no_sparse不允许值为空
+	3. 再校验键值对个数 min_pairs、max_pairs
+*/
+func (p *mapPlan) check(raw any, path string, opts *Options, tr *FieldTrace) (result ValidationResult) {
+	pairs, ok := raw.(map[string]any)
+	if !ok {
+		return ValidationResult{newViolation("type", raw, typeMismatch("object", raw).Error())}
+	}
+	if p.ignoreEmpty && len(pairs) == 0 {
+		tr.ignoreEmpty()
+		return
+	}
+
+	// JSON对象的键总是字符串，按照宽松模式转换为键类型
+	key_opts := *opts
+	key_opts.Lenient = true
+
+	pair_tr := tr.elem()
+	for _, k := range sortedKeys(pairs) {
+		name := fmt.Sprintf("%s[%q]", path, k)
+		result = append(result, p.keys.check(k, &key_opts, pair_tr).at(name)...)
+		if pairs[k] == nil && p.values.typ == "message" {
+			if p.noSparse {
+				v := newViolation("map.no_sparse", nil, "值不能为空(no_sparse)")
+				v.Path, v.Param = name, true
+				result = append(result, v)
+			}
+		} else {
+			result = append(result, p.values.check(pairs[k], opts, pair_tr).at(name)...)
+		}
+	}
+	tr.value(raw)
+
+	if !p.hasRules {
+		return
+	}
+	if p.noSparse {
+		tr.rule("map.no_sparse", true)
+	}
+	return append(result, validateRules(pairs, p.rules, tr)...)
+}
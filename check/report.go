@@ -0,0 +1,311 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 校验结果的输出格式
+const (
+	FormatText  = "text"  // 文本，默认
+	FormatJSON  = "json"  // JSON报告
+	FormatJUnit = "junit" // JUnit XML，CI中每个数据文件是一个测试用例
+	FormatSARIF = "sarif" // SARIF 2.1.0，用于在数据文件中标注错误
+)
+
+// 一次校验的报告
+type Report struct {
+	Message string           // 根消息的全限定名
+	Payload *Payload         // 待校验的数据
+	Result  ValidationResult // 校验结果
+}
+
+// 按照格式输出报告，文本格式见outputValidationResult
+func WriteReport(w io.Writer, format string, report Report) error {
+	switch format {
+	case FormatText:
+		outputValidationResult(w, report.Result)
+		return nil
+	case FormatJSON:
+		return writeJSONReport(w, report)
+	case FormatJUnit:
+		return writeJUnitReport(w, report)
+	case FormatSARIF:
+		return writeSARIFReport(w, report)
+	}
+	return fmt.Errorf("不支持的输出格式 %s", format)
+}
+
+// 参数和值中的时长、时间按照文本输出，如 1.5s。数值保持为数值
+func reportValue(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		return val
+	case time.Duration:
+		return val.String()
+	case []Duration:
+		texts := make([]string, 0, len(val))
+		for _, d := range val {
+			texts = append(texts, d.String())
+		}
+		return texts
+	case map[string]any:
+		// 范围规则的参数，如 {"gt": 1s, "lt": 10s}
+		bounds := make(map[string]any, len(val))
+		for name, bound := range val {
+			bounds[name] = reportValue(bound)
+		}
+		return bounds
+	case fmt.Stringer:
+		return val.String()
+	}
+	return v
+}
+
+func reportViolations(result ValidationResult) []Violation {
+	violations := make([]Violation, 0, len(result))
+	for _, v := range result {
+		v.Param, v.Value = reportValue(v.Param), reportValue(v.Value)
+		violations = append(violations, v)
+	}
+	return violations
+}
+
+func writeJSONReport(w io.Writer, report Report) error {
+	out, err := json.MarshalIndent(struct {
+		Message    string      `json:"message"`
+		Payload    string      `json:"payload"`
+		Valid      bool        `json:"valid"`
+		Violations []Violation `json:"violations"`
+	}{report.Message, report.Payload.Path, report.Result.Valid(), reportViolations(report.Result)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// 每个数据文件是一个测试用例，所有错误记录在同一个failure中
+func writeJUnitReport(w io.Writer, report Report) error {
+	testCase := junitTestCase{Name: report.Payload.Path, Classname: report.Message}
+	failures := 0
+	if !report.Result.Valid() {
+		failures = 1
+		var text strings.Builder
+		for _, v := range report.Result {
+			fmt.Fprintf(&text, "%s: [%s] %s\n", v.Path, v.Rule, v.Message)
+		}
+		testCase.Failure = &junitFailure{
+			Message: fmt.Sprintf("%d 个校验错误", len(report.Result)),
+			Type:    "validation",
+			Text:    text.String(),
+		}
+	}
+
+	suites := junitTestSuites{Suites: []junitTestSuite{{
+		Name:     report.Message,
+		Tests:    1,
+		Failures: failures,
+		Cases:    []junitTestCase{testCase},
+	}}}
+	out, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, out)
+	return err
+}
+
+/*
+*
+
+	SARIF 2.1.0 报告
+	1. 每个错误是一个result，ruleId为规则id
+	2. 位置指向数据文件，JSON数据可以定位到字段所在的行，其余格式只定位到文件
+*/
+func writeSARIFReport(w io.Writer, report Report) error {
+	var lines map[string]int
+	if report.Payload.Format == PayloadJSON {
+		lines = jsonPathLines(report.Payload.Raw)
+	}
+
+	type object = map[string]any
+	rules := []object{}
+	seen := make(map[string]bool)
+	results := make([]object, 0, len(report.Result))
+	for _, v := range report.Result {
+		if !seen[v.Rule] {
+			seen[v.Rule] = true
+			rules = append(rules, object{"id": v.Rule})
+		}
+
+		location := object{
+			"physicalLocation": object{"artifactLocation": object{"uri": report.Payload.Path}},
+			"logicalLocations": []object{{"fullyQualifiedName": v.Path, "kind": "member"}},
+		}
+		if line, ok := lookupPathLine(lines, v.Path); ok {
+			location["physicalLocation"].(object)["region"] = object{"startLine": line}
+		}
+		results = append(results, object{
+			"ruleId":    v.Rule,
+			"level":     "error",
+			"message":   object{"text": fmt.Sprintf("%s: %s", v.Path, v.Message)},
+			"locations": []object{location},
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i]["id"].(string) < rules[j]["id"].(string) })
+
+	out, err := json.MarshalIndent(object{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []object{{
+			"tool":    object{"driver": object{"name": "protoc-gen-check", "rules": rules}},
+			"results": results,
+		}},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+// 路径中的一段：.name、[3]、["key"]
+var pathSegmentPattern = regexp.MustCompile(`\["((?:[^"\\]|\\.)*)"\]|\[(\d+)\]|\.?([^.\[]+)`)
+
+// 路径的统一形式：字段名和map的键都写成 .key 并去掉下划线、转为小写，
+// 使proto字段名与json_name写法一致，如 face_info["k"] 与 faceInfo.k 相同
+func normalizePathKey(key string) string {
+	return "." + strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
+
+func normalizePath(path string) string {
+	var b strings.Builder
+	for _, m := range pathSegmentPattern.FindAllStringSubmatch(path, -1) {
+		switch {
+		case m[2] != "":
+			b.WriteString("[" + m[2] + "]")
+		case m[3] != "":
+			b.WriteString(normalizePathKey(m[3]))
+		default:
+			key, err := strconv.Unquote(`"` + m[1] + `"`)
+			if err != nil {
+				key = m[1]
+			}
+			b.WriteString(normalizePathKey(key))
+		}
+	}
+	return b.String()
+}
+
+// 查找路径所在的行，路径本身不在数据中时（如未设置的字段）使用最近的上一级
+func lookupPathLine(lines map[string]int, path string) (int, bool) {
+	if lines == nil {
+		return 0, false
+	}
+	for p := normalizePath(path); ; {
+		if line, ok := lines[p]; ok {
+			return line, true
+		}
+		i := strings.LastIndexAny(p, ".[")
+		if i < 0 {
+			return 0, false
+		}
+		p = p[:i]
+	}
+}
+
+// JSON数据中每个值的路径（统一形式）所在的行，根对象的路径为空
+func jsonPathLines(raw []byte) map[string]int {
+	lines := make(map[string]int)
+	lineOf := func(offset int64) int {
+		return bytes.Count(raw[:offset], []byte("\n")) + 1
+	}
+
+	type container struct {
+		path    string
+		isArray bool
+		index   int
+		key     string // 对象中下一个值的键
+		onKey   bool   // 对象中下一个token是否为键
+	}
+	var stack []*container
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		// 对象的键，记录键所在的行
+		path := ""
+		if n := len(stack); n > 0 {
+			top := stack[n-1]
+			if !top.isArray && top.onKey {
+				top.key, top.onKey = token.(string), false
+				lines[top.path+normalizePathKey(top.key)] = lineOf(decoder.InputOffset())
+				continue
+			}
+			if top.isArray {
+				path = fmt.Sprintf("%s[%d]", top.path, top.index)
+				top.index++
+				lines[path] = lineOf(skipSpace(raw, offset))
+			} else {
+				path = top.path + normalizePathKey(top.key)
+				top.onKey = true
+			}
+		} else {
+			lines[path] = lineOf(skipSpace(raw, offset))
+		}
+
+		if delim, ok := token.(json.Delim); ok {
+			stack = append(stack, &container{path: path, isArray: delim == '[', onKey: delim == '{'})
+		}
+	}
+	return lines
+}
+
+// 跳过空白和逗号，得到下一个token的起始位置
+func skipSpace(raw []byte, offset int64) int64 {
+	for offset < int64(len(raw)) && strings.IndexByte(" \t\r\n,:", raw[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
@@ -0,0 +1,778 @@
+package check
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/envoyproxy/protoc-gen-validate/validate"
+)
+
+// Number 是一个泛型约束，表示可以是以下任意一种数值类型
+type Number interface {
+	uint32 | uint64 | int32 | int64 | float32 | float64
+}
+
+type ruleFuncGetter[T any, V any] func(V) RuleFunc[T]
+type RuleFunc[T any] func(T) (bool, string)
+
+// 比较函数，返回 a < b
+type LessFunc[T any] func(a, b T) bool
+
+/*
+*
+
+	取值范围规则，与PGV对 lt、lte、gt、gte 的语义一致
+	1. 只有上界或下界时，值必须小于(等于)上界或大于(等于)下界
+	2. 同时有上界和下界且上界大于下界时，值必须在区间内，如 gt: 5, lt: 10 表示 5 < x < 10
+	3. 同时有上界和下界且上界不大于下界时，值必须在区间外，如 gt: 10, lt: 5 表示 x < 5 或 x > 10
+	没有任何边界时返回nil
+*/
+func RangeRule[T any](lt, lte, gt, gte *T, less LessFunc[T], kind string) RuleFunc[T] {
+	upper, upper_inclusive := lt, false
+	if upper == nil && lte != nil {
+		upper, upper_inclusive = lte, true
+	}
+	lower, lower_inclusive := gt, false
+	if lower == nil && gte != nil {
+		lower, lower_inclusive = gte, true
+	}
+
+	// 值是否在上界之内: val < lt 或 val <= lte
+	below_upper := func(val T) bool {
+		if upper_inclusive {
+			return !less(*upper, val)
+		}
+		return less(val, *upper)
+	}
+	// 值是否在下界之内: val > gt 或 val >= gte
+	above_lower := func(val T) bool {
+		if lower_inclusive {
+			return !less(val, *lower)
+		}
+		return less(*lower, val)
+	}
+	bracket := func(inclusive bool, open string, closed string) string {
+		if inclusive {
+			return closed
+		}
+		return open
+	}
+
+	switch {
+	case upper != nil && lower != nil && less(*lower, *upper):
+		left, right := bracket(lower_inclusive, "(", "["), bracket(upper_inclusive, ")", "]")
+		return func(val T) (bool, string) {
+			if above_lower(val) && below_upper(val) {
+				return true, ""
+			}
+			message := fmt.Sprintf("%s %v 必须在区间 %s%v, %v%s 内", kind, val, left, *lower, *upper, right)
+			return false, message
+		}
+	case upper != nil && lower != nil:
+		left, right := bracket(upper_inclusive, "[", "("), bracket(lower_inclusive, "]", ")")
+		return func(val T) (bool, string) {
+			if below_upper(val) || above_lower(val) {
+				return true, ""
+			}
+			message := fmt.Sprintf("%s %v 必须在区间 %s%v, %v%s 之外", kind, val, left, *upper, *lower, right)
+			return false, message
+		}
+	case upper != nil:
+		op := bracket(upper_inclusive, "小于", "小于等于")
+		return func(val T) (bool, string) {
+			if below_upper(val) {
+				return true, ""
+			}
+			message := fmt.Sprintf("%s %v 必须%s%v", kind, val, op, *upper)
+			return false, message
+		}
+	case lower != nil:
+		op := bracket(lower_inclusive, "大于", "大于等于")
+		return func(val T) (bool, string) {
+			if above_lower(val) {
+				return true, ""
+			}
+			message := fmt.Sprintf("%s %v 必须%s%v", kind, val, op, *lower)
+			return false, message
+		}
+	}
+	return nil
+}
+
+func NumberRange[T Number](lt, lte, gt, gte *T) RuleFunc[T] {
+	rule := RangeRule(lt, lte, gt, gte, func(a, b T) bool { return a < b }, "数值")
+	if rule == nil {
+		return nil
+	}
+	return func(val T) (bool, string) {
+		// NaN与任何值比较都不成立，与PGV一致视为满足范围
+		if val != val {
+			return true, ""
+		}
+		return rule(val)
+	}
+}
+
+// 规则编译时将数组转换为集合，校验时只需查找一次
+func newSet[T comparable](arr []T) map[T]struct{} {
+	set := make(map[T]struct{}, len(arr))
+	for _, v := range arr {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+func ScalarIn[T Number | string](right []T) RuleFunc[T] {
+	set := newSet(right)
+	return func(val T) (bool, string) {
+		if _, ok := set[val]; ok {
+			return true, ""
+		}
+		message := fmt.Sprintf("数值 %v 应该在数组 %v", val, right)
+		return false, message
+	}
+}
+
+func ScalarNotIn[T Number | string](right []T) RuleFunc[T] {
+	set := newSet(right)
+	return func(val T) (bool, string) {
+		if _, ok := set[val]; !ok {
+			return true, ""
+		}
+		message := fmt.Sprintf("数值 %v 不应该在数组 %v", val, right)
+		return false, message
+	}
+}
+
+func ScalarConst[T Number | bool | string](right T) RuleFunc[T] {
+	return func(val T) (bool, string) {
+		if val == right {
+			return true, ""
+		}
+		message := fmt.Sprintf("值 %v 必须等于%v", val, right)
+		return false, message
+	}
+}
+
+// 长度按字符(rune)计算，与PGV的len一致。字节数使用StringLenBytes
+func StringLen(right uint64) RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if uint64(utf8.RuneCountInString(val)) == right {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 字符数必须等于%v", val, right)
+		return false, message
+	}
+}
+
+func StringMinLen(right uint64) RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if uint64(utf8.RuneCountInString(val)) >= right {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 字符数必须>=%v", val, right)
+		return false, message
+	}
+}
+
+func StringMaxLen(right uint64) RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if uint64(utf8.RuneCountInString(val)) <= right {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 字符数必须<=%v", val, right)
+		return false, message
+	}
+}
+
+func StringLenBytes(right uint64) RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if uint64(len([]byte(val))) == right {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 字节数必须等于%v", val, right)
+		return false, message
+	}
+}
+
+func StringMinBytes(right uint64) RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if uint64(len([]byte(val))) >= right {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 字节数必须>=%v", val, right)
+		return false, message
+	}
+}
+
+func StringMaxBytes(right uint64) RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if uint64(len([]byte(val))) <= right {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 字节数必须<=%v", val, right)
+		return false, message
+	}
+}
+
+// 正则表达式在编译规则时只编译一次，无法编译时返回错误
+func StringPattern(pattern string) (RuleFunc[string], error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("正则表达式错误: %w", err)
+	}
+	return func(val string) (bool, string) {
+		if re.MatchString(val) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 不匹配模式 %v", val, pattern)
+		return false, message
+	}, nil
+}
+
+func StringPrefix(prefix string) RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if strings.HasPrefix(val, prefix) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 没有前缀 %v", val, prefix)
+		return false, message
+	}
+}
+
+func StringSuffix(suffix string) RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if strings.HasSuffix(val, suffix) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 没有后缀 %v", val, suffix)
+		return false, message
+	}
+}
+
+func StringContains(substr string) RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if strings.Contains(val, substr) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 不包含 %v", val, substr)
+		return false, message
+	}
+}
+
+func StringNotContains(substr string) RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if !strings.Contains(val, substr) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 不应包含 %v", val, substr)
+		return false, message
+	}
+}
+
+func StringEmail() RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if err := validateEmail(val); err != nil {
+			message := fmt.Sprintf("字符串 %v 不是合法的邮箱地址: %v", val, err)
+			return false, message
+		}
+		return true, ""
+	}
+}
+
+func StringHostname() RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if err := validateHostname(val); err != nil {
+			message := fmt.Sprintf("字符串 %v 不是合法的主机名: %v", val, err)
+			return false, message
+		}
+		return true, ""
+	}
+}
+
+func StringIP() RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if ip := net.ParseIP(val); ip != nil {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 不是合法的IP地址", val)
+		return false, message
+	}
+}
+
+func StringIPv4() RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if ip := net.ParseIP(val); ip != nil && ip.To4() != nil {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 不是合法的IPv4地址", val)
+		return false, message
+	}
+}
+
+func StringIPv6() RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if ip := net.ParseIP(val); ip != nil && ip.To4() == nil {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 不是合法的IPv6地址", val)
+		return false, message
+	}
+}
+
+// 主机名或IP地址
+func StringAddress() RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if validateHostname(val) == nil || net.ParseIP(val) != nil {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 不是合法的主机名或IP地址", val)
+		return false, message
+	}
+}
+
+// 绝对URI
+func StringURI() RuleFunc[string] {
+	return func(val string) (bool, string) {
+		uri, err := url.Parse(val)
+		if err != nil {
+			message := fmt.Sprintf("字符串 %v 不是合法的URI: %v", val, err)
+			return false, message
+		}
+		if !uri.IsAbs() {
+			message := fmt.Sprintf("字符串 %v 必须是绝对URI", val)
+			return false, message
+		}
+		return true, ""
+	}
+}
+
+// URI引用，可以是相对路径
+func StringURIRef() RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if _, err := url.Parse(val); err != nil {
+			message := fmt.Sprintf("字符串 %v 不是合法的URI引用: %v", val, err)
+			return false, message
+		}
+		return true, ""
+	}
+}
+
+var uuidPattern = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
+
+func StringUUID() RuleFunc[string] {
+	return func(val string) (bool, string) {
+		if uuidPattern.MatchString(val) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %v 不是合法的UUID", val)
+		return false, message
+	}
+}
+
+// RFC 7230 中的HTTP头，与PGV的定义一致。strict为false时只禁止 \r\n\0
+var (
+	httpHeaderNamePattern  = regexp.MustCompile(`^:?[0-9a-zA-Z!#$%&'*+-.^_|~\x60]+$`)
+	httpHeaderValuePattern = regexp.MustCompile(`^[^\x00-\x08\x0A-\x1F\x7F]*$`)
+	headerStringPattern    = regexp.MustCompile(`^[^\x00\x0A\x0D]*$`)
+)
+
+func StringWellKnownRegex(known validate.KnownRegex, strict bool) RuleFunc[string] {
+	pattern := headerStringPattern
+	desc := "HTTP头"
+	switch known {
+	case validate.KnownRegex_HTTP_HEADER_NAME:
+		desc = "HTTP头名称"
+		if strict {
+			pattern = httpHeaderNamePattern
+		}
+	case validate.KnownRegex_HTTP_HEADER_VALUE:
+		desc = "HTTP头的值"
+		if strict {
+			pattern = httpHeaderValuePattern
+		}
+	}
+	return func(val string) (bool, string) {
+		if pattern.MatchString(val) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字符串 %q 不是合法的%s", val, desc)
+		return false, message
+	}
+}
+
+func validateHostname(host string) error {
+	s := strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if len(host) > 253 {
+		return errors.New("主机名不能超过253个字符")
+	}
+
+	for _, part := range strings.Split(s, ".") {
+		if l := len(part); l == 0 || l > 63 {
+			return errors.New("主机名的每一段不能为空且不能超过63个字符")
+		}
+		if part[0] == '-' {
+			return errors.New("主机名的每一段不能以连字符开头")
+		}
+		if part[len(part)-1] == '-' {
+			return errors.New("主机名的每一段不能以连字符结尾")
+		}
+		for _, r := range part {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return fmt.Errorf("主机名只能包含字母、数字和连字符，不能包含 %q", string(r))
+			}
+		}
+	}
+	return nil
+}
+
+func validateEmail(addr string) error {
+	a, err := mail.ParseAddress(addr)
+	if err != nil {
+		return err
+	}
+	addr = a.Address
+
+	if len(addr) > 254 {
+		return errors.New("邮箱地址不能超过254个字符")
+	}
+
+	parts := strings.SplitN(addr, "@", 2)
+	if len(parts[0]) > 64 {
+		return errors.New("邮箱地址的本地部分不能超过64个字符")
+	}
+	return validateHostname(parts[1])
+}
+
+func BytesConst(right []byte) RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		if bytes.Equal(val, right) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字节串 %q 必须等于%q", val, right)
+		return false, message
+	}
+}
+
+func BytesLen(right uint64) RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		if uint64(len(val)) == right {
+			return true, ""
+		}
+		message := fmt.Sprintf("字节串 %q 长度必须等于%v", val, right)
+		return false, message
+	}
+}
+
+func BytesMinLen(right uint64) RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		if uint64(len(val)) >= right {
+			return true, ""
+		}
+		message := fmt.Sprintf("字节串 %q 长度必须>=%v", val, right)
+		return false, message
+	}
+}
+
+func BytesMaxLen(right uint64) RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		if uint64(len(val)) <= right {
+			return true, ""
+		}
+		message := fmt.Sprintf("字节串 %q 长度必须<=%v", val, right)
+		return false, message
+	}
+}
+
+// 与StringPattern相同，正则表达式无法编译时返回错误
+func BytesPattern(pattern string) (RuleFunc[[]byte], error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("正则表达式错误: %w", err)
+	}
+	return func(val []byte) (bool, string) {
+		if re.Match(val) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字节串 %q 不匹配模式 %v", val, pattern)
+		return false, message
+	}, nil
+}
+
+func BytesPrefix(prefix []byte) RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		if bytes.HasPrefix(val, prefix) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字节串 %q 没有前缀 %q", val, prefix)
+		return false, message
+	}
+}
+
+func BytesSuffix(suffix []byte) RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		if bytes.HasSuffix(val, suffix) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字节串 %q 没有后缀 %q", val, suffix)
+		return false, message
+	}
+}
+
+func BytesContains(substr []byte) RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		if bytes.Contains(val, substr) {
+			return true, ""
+		}
+		message := fmt.Sprintf("字节串 %q 不包含 %q", val, substr)
+		return false, message
+	}
+}
+
+func BytesIn(right [][]byte) RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		for _, r := range right {
+			if bytes.Equal(val, r) {
+				return true, ""
+			}
+		}
+		message := fmt.Sprintf("字节串 %q 应该在数组 %q", val, right)
+		return false, message
+	}
+}
+
+func BytesNotIn(right [][]byte) RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		for _, r := range right {
+			if bytes.Equal(val, r) {
+				message := fmt.Sprintf("字节串 %q 不应该在数组 %q", val, right)
+				return false, message
+			}
+		}
+		return true, ""
+	}
+}
+
+func BytesIP() RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		if len(val) == net.IPv4len || len(val) == net.IPv6len {
+			return true, ""
+		}
+		message := fmt.Sprintf("字节串 %x 不是合法的IP地址，长度必须为4或16", val)
+		return false, message
+	}
+}
+
+func BytesIPv4() RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		if len(val) == net.IPv4len {
+			return true, ""
+		}
+		message := fmt.Sprintf("字节串 %x 不是合法的IPv4地址，长度必须为4", val)
+		return false, message
+	}
+}
+
+func BytesIPv6() RuleFunc[[]byte] {
+	return func(val []byte) (bool, string) {
+		if len(val) == net.IPv6len {
+			return true, ""
+		}
+		message := fmt.Sprintf("字节串 %x 不是合法的IPv6地址，长度必须为16", val)
+		return false, message
+	}
+}
+
+func RepeatedMinItems(right uint64) RuleFunc[[]any] {
+	return func(val []any) (bool, string) {
+		if uint64(len(val)) >= right {
+			return true, ""
+		}
+		message := fmt.Sprintf("元素个数 %v 必须>=%v", len(val), right)
+		return false, message
+	}
+}
+
+func RepeatedMaxItems(right uint64) RuleFunc[[]any] {
+	return func(val []any) (bool, string) {
+		if uint64(len(val)) <= right {
+			return true, ""
+		}
+		message := fmt.Sprintf("元素个数 %v 必须<=%v", len(val), right)
+		return false, message
+	}
+}
+
+// 元素必须互不相同，path用于指出重复的元素。值为nil的元素（类型不正确）不参与比较
+func RepeatedUnique(path string) RuleFunc[[]any] {
+	return func(val []any) (bool, string) {
+		seen := make(map[any]int)
+		for i, v := range val {
+			if v == nil {
+				continue
+			}
+			key := v
+			if b, ok := v.([]byte); ok {
+				key = string(b)
+			}
+			if j, ok := seen[key]; ok {
+				message := fmt.Sprintf("%s[%d] 的值 %v 与 %s[%d] 重复", path, i, v, path, j)
+				return false, message
+			}
+			seen[key] = i
+		}
+		return true, ""
+	}
+}
+
+func MapMinPairs(right uint64) RuleFunc[map[string]any] {
+	return func(val map[string]any) (bool, string) {
+		if uint64(len(val)) >= right {
+			return true, ""
+		}
+		message := fmt.Sprintf("键值对个数 %v 必须>=%v", len(val), right)
+		return false, message
+	}
+}
+
+func MapMaxPairs(right uint64) RuleFunc[map[string]any] {
+	return func(val map[string]any) (bool, string) {
+		if uint64(len(val)) <= right {
+			return true, ""
+		}
+		message := fmt.Sprintf("键值对个数 %v 必须<=%v", len(val), right)
+		return false, message
+	}
+}
+
+func TimestampConst(right Timestamp) RuleFunc[Timestamp] {
+	return func(val Timestamp) (bool, string) {
+		if val.Equal(right.Time) {
+			return true, ""
+		}
+		message := fmt.Sprintf("时间 %v 必须等于%v", val, right)
+		return false, message
+	}
+}
+
+func TimestampRange(lt, lte, gt, gte *Timestamp) RuleFunc[Timestamp] {
+	return RangeRule(lt, lte, gt, gte, func(a, b Timestamp) bool { return a.Before(b.Time) }, "时间")
+}
+
+// 与当前时间比较，每次校验时取当前时间
+func TimestampLtNow() RuleFunc[Timestamp] {
+	return func(val Timestamp) (bool, string) {
+		now := time.Now()
+		if val.Before(now) {
+			return true, ""
+		}
+		message := fmt.Sprintf("时间 %v 必须早于当前时间 %v", val, Timestamp{now})
+		return false, message
+	}
+}
+
+func TimestampGtNow() RuleFunc[Timestamp] {
+	return func(val Timestamp) (bool, string) {
+		now := time.Now()
+		if val.After(now) {
+			return true, ""
+		}
+		message := fmt.Sprintf("时间 %v 必须晚于当前时间 %v", val, Timestamp{now})
+		return false, message
+	}
+}
+
+// 与当前时间相差不超过within，与lt_now、gt_now同时使用时各自校验
+func TimestampWithin(within time.Duration) RuleFunc[Timestamp] {
+	return func(val Timestamp) (bool, string) {
+		now := time.Now()
+		diff := now.Sub(val.Time)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= within {
+			return true, ""
+		}
+		message := fmt.Sprintf("时间 %v 与当前时间 %v 相差必须在 %v 以内", val, Timestamp{now}, within)
+		return false, message
+	}
+}
+
+func DurationConst(right Duration) RuleFunc[Duration] {
+	return func(val Duration) (bool, string) {
+		if val == right {
+			return true, ""
+		}
+		message := fmt.Sprintf("时长 %v 必须等于%v", val, right)
+		return false, message
+	}
+}
+
+func DurationRange(lt, lte, gt, gte *Duration) RuleFunc[Duration] {
+	return RangeRule(lt, lte, gt, gte, durationLess, "时长")
+}
+
+func DurationIn(right []Duration) RuleFunc[Duration] {
+	set := newSet(right)
+	return func(val Duration) (bool, string) {
+		if _, ok := set[val]; ok {
+			return true, ""
+		}
+		message := fmt.Sprintf("时长 %v 应该在数组 %v", val, right)
+		return false, message
+	}
+}
+
+func DurationNotIn(right []Duration) RuleFunc[Duration] {
+	set := newSet(right)
+	return func(val Duration) (bool, string) {
+		if _, ok := set[val]; !ok {
+			return true, ""
+		}
+		message := fmt.Sprintf("时长 %v 不应该在数组 %v", val, right)
+		return false, message
+	}
+}
+
+// Any只校验type_url
+func AnyIn(right []string) RuleFunc[string] {
+	set := newSet(right)
+	return func(val string) (bool, string) {
+		if _, ok := set[val]; ok {
+			return true, ""
+		}
+		message := fmt.Sprintf("Any类型 %v 应该在数组 %v", val, right)
+		return false, message
+	}
+}
+
+func AnyNotIn(right []string) RuleFunc[string] {
+	set := newSet(right)
+	return func(val string) (bool, string) {
+		if _, ok := set[val]; !ok {
+			return true, ""
+		}
+		message := fmt.Sprintf("Any类型 %v 不应该在数组 %v", val, right)
+		return false, message
+	}
+}
+
+func EnumDefinedOnly(values []int32) RuleFunc[int32] {
+	set := newSet(values)
+	return func(val int32) (bool, string) {
+		if _, ok := set[val]; ok {
+			return true, ""
+		}
+		message := fmt.Sprintf("枚举值 %v 不合法: %v", val, values)
+		return false, message
+	}
+}
@@ -0,0 +1,170 @@
+package check
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	pgs "github.com/lyft/protoc-gen-star/v2"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+/*
+*
+
+	严格模式：数据中不属于消息字段的键视为错误
+	1. 键可以是proto字段名或json_name，其余的键均为未知字段
+	2. 提示编辑距离最接近的字段名，帮助发现拼写错误
+	3. 键与消息中保留(reserved)的字段名或字段编号相同时给出说明
+*/
+func checkUnknownKeys(p *messagePlan, rawData map[string]any, path string) (result ValidationResult) {
+	var unknown []string
+	for key := range rawData {
+		if !p.known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	for _, key := range unknown {
+		msg := fmt.Sprintf("未知字段 %s", key)
+		if reserved := reservedMatch(p.message, key); reserved != "" {
+			msg += "，" + reserved
+		}
+		if suggestion := closestName(key, p.names); suggestion != "" {
+			msg += fmt.Sprintf("，是否应为 %s", suggestion)
+		}
+		v := newViolation("unknown_field", rawData[key], msg)
+		v.Path, v.Field = path+key, strings.TrimPrefix(p.message.FullyQualifiedName(), ".")
+		result = append(result, v)
+	}
+	return
+}
+
+/*
+*
+
+	严格模式：二进制数据中的未知字段，按字段编号报告，如 data.7
+	1. 同一个编号出现多次时合并为一条错误，值为这些字段的原始编码
+	2. 字段编号已被保留(reserved)时给出说明
+	3. 递归检查嵌套消息以及repeated、map中的消息，path为消息所在的路径
+*/
+func checkUnknownWire(msg protoreflect.Message, path string) (result ValidationResult) {
+	md := msg.Descriptor()
+	var nums []protowire.Number
+	raws := make(map[protowire.Number][]byte)
+	for b := msg.GetUnknown(); len(b) > 0; {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			break
+		}
+		m := protowire.ConsumeFieldValue(num, typ, b[n:])
+		if m < 0 {
+			break
+		}
+		if _, ok := raws[num]; !ok {
+			nums = append(nums, num)
+		}
+		raws[num] = append(raws[num], b[:n+m]...)
+		b = b[n+m:]
+	}
+	sort.Slice(nums, func(i, j int) bool { return nums[i] < nums[j] })
+
+	for _, num := range nums {
+		text := fmt.Sprintf("未知字段 %d", num)
+		if md.ReservedRanges().Has(num) {
+			text += fmt.Sprintf("，字段编号 %d 已被保留(reserved)", num)
+		}
+		v := newViolation("unknown_field", raws[num], text)
+		v.Path, v.Field = path+strconv.Itoa(int(num)), string(md.FullName())
+		result = append(result, v)
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !msg.Has(fd) {
+			continue
+		}
+		name := path + string(fd.Name())
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := msg.Get(fd).List()
+			for j := 0; j < list.Len(); j++ {
+				result = append(result, checkUnknownWire(list.Get(j).Message(), fmt.Sprintf("%s[%d].", name, j))...)
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			pairs := make(map[string]protoreflect.Message)
+			msg.Get(fd).Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				pairs[k.String()] = v.Message()
+				return true
+			})
+			keys := make([]string, 0, len(pairs))
+			for k := range pairs {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				result = append(result, checkUnknownWire(pairs[k], fmt.Sprintf("%s[%q].", name, k))...)
+			}
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			result = append(result, checkUnknownWire(msg.Get(fd).Message(), name+".")...)
+		}
+	}
+	return
+}
+
+// 键与保留的字段名或字段编号相同时返回说明
+func reservedMatch(m pgs.Message, key string) string {
+	for _, name := range m.Descriptor().GetReservedName() {
+		if name == key {
+			return fmt.Sprintf("字段名 %s 已被保留(reserved)", key)
+		}
+	}
+	if num, err := strconv.Atoi(key); err == nil {
+		for _, r := range m.Descriptor().GetReservedRange() {
+			// 保留范围不包含end
+			if int(r.GetStart()) <= num && num < int(r.GetEnd()) {
+				return fmt.Sprintf("字段编号 %d 已被保留(reserved)", num)
+			}
+		}
+	}
+	return ""
+}
+
+// 编辑距离最小的字段名，距离相同时取先出现的。
+// 距离超过 max(2, 键长度/3) 时不像是拼写错误，不给出提示
+func closestName(key string, names []string) string {
+	closest, best := "", max(2, utf8.RuneCountInString(key)/3)+1
+	for _, name := range names {
+		if d := editDistance(key, name); d < best {
+			closest, best = name, d
+		}
+	}
+	return closest
+}
+
+// Levenshtein距离，按字符(rune)计算
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
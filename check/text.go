@@ -0,0 +1,42 @@
+package check
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// 文本格式数据的解析错误
+type TextError struct {
+	Line   int // 出错位置的行号，从1开始
+	Column int // 出错位置的列号，从1开始
+	Err    error
+}
+
+func (e *TextError) Error() string {
+	return fmt.Sprintf("文本数据在第 %d 行第 %d 列解析失败: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *TextError) Unwrap() error { return e.Err }
+
+// prototext的错误信息形如 "proto: syntax error (line 3:5): ..."
+var textErrorPosition = regexp.MustCompile(`\(line (\d+):(\d+)\)`)
+
+// 解码文本格式的数据，并转换为与JSON一致的数据形式，走同样的校验流程
+func decodeText(raw []byte, md protoreflect.MessageDescriptor) (map[string]any, error) {
+	msg := dynamicpb.NewMessage(md)
+	// 必填字段由checkRequired校验，这里允许缺失
+	if err := (prototext.UnmarshalOptions{AllowPartial: true}).Unmarshal(raw, msg); err != nil {
+		if m := textErrorPosition.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			column, _ := strconv.Atoi(m[2])
+			return nil, &TextError{Line: line, Column: column, Err: err}
+		}
+		return nil, fmt.Errorf("解析文本数据失败: %w", err)
+	}
+	return messageToMap(msg), nil
+}
@@ -0,0 +1,83 @@
+package check
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 一个字段的校验过程，每次校验单独记录，通过 Options.Trace 输出
+type FieldTrace struct {
+	Path        string         // 字段在数据中的路径
+	Type        string         // 字段的proto类型，如 TYPE_STRING
+	Required    bool           // 字段是否必须设置
+	Value       string         // 数据中的值
+	IgnoreEmpty bool           // 是否因为 ignore_empty 跳过了校验
+	Rules       map[string]any // 执行过的规则及参数，如 min_len=3
+}
+
+func newFieldTrace(path string, typ string) *FieldTrace {
+	return &FieldTrace{Path: path, Type: typ, Rules: make(map[string]any)}
+}
+
+// 记录执行的规则，键为规则id去掉类型前缀，如 string.min_len -> min_len。
+// 范围规则按边界分别记录，如 gt=1,lt=10。
+// 未设置 Options.Trace 时t为nil，以下记录方法都不做任何事，校验时不产生额外的分配
+func (t *FieldTrace) rule(id string, param any) {
+	if t == nil {
+		return
+	}
+	if bounds, ok := param.(map[string]any); ok {
+		for name, bound := range bounds {
+			t.Rules[name] = bound
+		}
+		return
+	}
+	t.Rules[id[strings.IndexByte(id, '.')+1:]] = param
+}
+
+// 记录数据中的值
+func (t *FieldTrace) value(raw any) {
+	if t != nil {
+		t.Value = fmt.Sprintf("%v", raw)
+	}
+}
+
+// 因为 ignore_empty 跳过了校验
+func (t *FieldTrace) ignoreEmpty() {
+	if t != nil {
+		t.IgnoreEmpty = true
+	}
+}
+
+// repeated的元素、map的键值对只向字段记录执行的规则，值和 ignore_empty 不影响字段
+func (t *FieldTrace) elem() *FieldTrace {
+	if t == nil {
+		return nil
+	}
+	return &FieldTrace{Rules: t.Rules}
+}
+
+// 形如 required TYPE_STRING data.ip = 127.0.0.1 [ip=true,]，规则按名称排序
+func (t *FieldTrace) String() string {
+	res := "optional "
+	if t.Required {
+		res = "required "
+	}
+	res += t.Type + " " + t.Path
+	if t.IgnoreEmpty {
+		return res + " = \"\" [ignore_empty]"
+	}
+
+	names := make([]string, 0, len(t.Rules))
+	for name := range t.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	res += " = " + t.Value + " ["
+	for _, name := range names {
+		res += name + "=" + fmt.Sprintf("%v", t.Rules[name]) + ","
+	}
+	return res + "]"
+}
@@ -0,0 +1,54 @@
+package check
+
+import (
+	"reflect"
+	"strings"
+)
+
+// 一条校验错误
+type Violation struct {
+	Path    string `json:"path"`            // 值在数据中的路径，如 data.face_info.ip、tags[3]、labels["env"]
+	Field   string `json:"field"`           // 字段描述符的全限定名，如 example.FaceInfo.ip
+	Rule    string `json:"rule"`            // 规则id，如 string.min_len。类型不匹配、必填等不是PGV规则的错误为 type、required 等
+	Param   any    `json:"param,omitempty"` // 规则的参数，如 min_len 的 3，没有参数时为nil
+	Value   any    `json:"value,omitempty"` // 不符合规则的值，即数据中的原始值，字段未设置时为nil
+	Message string `json:"message"`         // 错误信息
+}
+
+// 校验结果，没有错误时为空
+type ValidationResult []Violation
+
+func (r ValidationResult) Valid() bool {
+	return len(r) == 0
+}
+
+// 为还没有路径的错误补充路径，repeated和map的元素已在校验时指定了路径
+func (r ValidationResult) at(path string) ValidationResult {
+	for i := range r {
+		if r[i].Path == "" {
+			r[i].Path = path
+		}
+	}
+	return r
+}
+
+// 不属于具体规则的错误，如类型不匹配
+func newViolation(rule string, value any, message string) Violation {
+	return Violation{Rule: rule, Value: value, Message: message}
+}
+
+// 一条校验规则，在RuleFunc的基础上记录规则id和参数
+type namedRule[T any] struct {
+	Id    string // 规则id，如 string.min_len
+	Param any    // 规则的参数
+	Check RuleFunc[T]
+}
+
+func newRule[T any](id string, param any, check RuleFunc[T]) namedRule[T] {
+	return namedRule[T]{Id: id, Param: param, Check: check}
+}
+
+// 规则id的前缀，validate.StringRules -> string，validate.SInt32Rules -> sint32
+func ruleIdPrefix(rval reflect.Value) string {
+	return strings.ToLower(strings.TrimSuffix(rval.Type().Name(), "Rules"))
+}
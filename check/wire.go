@@ -0,0 +1,229 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// 二进制数据的解析错误
+type WireError struct {
+	Offset int    // 出错位置的字节偏移
+	Path   string // 出错字段所在的路径
+	Err    error
+}
+
+func (e *WireError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("二进制数据在偏移 %d 处解析失败: %v", e.Offset, e.Err)
+	}
+	return fmt.Sprintf("二进制数据在偏移 %d 处(%s)解析失败: %v", e.Offset, e.Path, e.Err)
+}
+
+func (e *WireError) Unwrap() error { return e.Err }
+
+/*
+*
+
+	解码二进制数据
+	1. 先逐个字段扫描编码（包括嵌套消息和打包编码的repeated字段），定位错误的字节偏移
+	2. 再用dynamicpb解码，并转换为与JSON一致的数据形式，走同样的校验流程
+	3. 未知字段不在转换后的数据中，严格模式下根据返回的消息报告，见 checkUnknownWire
+*/
+func decodeWire(raw []byte, md protoreflect.MessageDescriptor) (map[string]any, protoreflect.Message, error) {
+	if err := scanWire(raw, md, 0, ""); err != nil {
+		return nil, nil, err
+	}
+
+	msg := dynamicpb.NewMessage(md)
+	// 必填字段在校验时检查，这里允许缺失
+	if err := (proto.UnmarshalOptions{AllowPartial: true}).Unmarshal(raw, msg); err != nil {
+		return nil, nil, fmt.Errorf("解析二进制数据失败: %w", err)
+	}
+	return messageToMap(msg), msg, nil
+}
+
+// 扫描一段消息的编码，base为该段数据在整个数据中的偏移
+func scanWire(b []byte, md protoreflect.MessageDescriptor, base int, path string) error {
+	for off := 0; off < len(b); {
+		num, typ, n := protowire.ConsumeTag(b[off:])
+		if n < 0 {
+			return &WireError{Offset: base + off, Path: path, Err: protowire.ParseError(n)}
+		}
+
+		var fd protoreflect.FieldDescriptor
+		name := fmt.Sprintf("%s%d", path, num)
+		if md != nil {
+			if fd = md.Fields().ByNumber(num); fd != nil {
+				name = path + string(fd.Name())
+			}
+		}
+
+		m := protowire.ConsumeFieldValue(num, typ, b[off+n:])
+		if m < 0 {
+			return &WireError{Offset: base + off + n, Path: name, Err: protowire.ParseError(m)}
+		}
+
+		// 嵌套消息递归扫描，打包编码的repeated标量逐个元素扫描
+		if fd != nil && typ == protowire.BytesType {
+			val, _ := protowire.ConsumeBytes(b[off+n:])
+			start := base + off + n + m - len(val)
+			var err error
+			if fd.Message() != nil {
+				err = scanWire(val, fd.Message(), start, name+".")
+			} else if elem, ok := packedType(fd.Kind()); ok && fd.IsList() {
+				err = scanPacked(val, elem, start, name)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		off += n + m
+	}
+	return nil
+}
+
+// 可以打包编码的标量的编码类型，字符串、字节串和消息不能打包
+func packedType(kind protoreflect.Kind) (protowire.Type, bool) {
+	switch kind {
+	case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.MessageKind, protoreflect.GroupKind:
+		return 0, false
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind, protoreflect.FloatKind:
+		return protowire.Fixed32Type, true
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind, protoreflect.DoubleKind:
+		return protowire.Fixed64Type, true
+	}
+	return protowire.VarintType, true
+}
+
+// 扫描打包编码的元素，错误指向具体的元素，如 verify_types[1]
+func scanPacked(b []byte, typ protowire.Type, base int, path string) error {
+	for off, i := 0, 0; off < len(b); i++ {
+		n := protowire.ConsumeFieldValue(0, typ, b[off:])
+		if n < 0 {
+			return &WireError{Offset: base + off, Path: fmt.Sprintf("%s[%d]", path, i), Err: protowire.ParseError(n)}
+		}
+		off += n
+	}
+	return nil
+}
+
+// 将解码后的消息转换为JSON对象形式，键为字段名，不包含未知字段
+func messageToMap(msg protoreflect.Message) map[string]any {
+	data := make(map[string]any)
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList():
+			list := v.List()
+			items := make([]any, 0, list.Len())
+			for i := 0; i < list.Len(); i++ {
+				items = append(items, valueToAny(fd, list.Get(i)))
+			}
+			data[string(fd.Name())] = items
+		case fd.IsMap():
+			pairs := make(map[string]any)
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				pairs[k.String()] = valueToAny(fd.MapValue(), mv)
+				return true
+			})
+			data[string(fd.Name())] = pairs
+		default:
+			data[string(fd.Name())] = valueToAny(fd, v)
+		}
+		return true
+	})
+	return data
+}
+
+func valueToAny(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return wellKnownToAny(v.Message())
+	case protoreflect.EnumKind:
+		return json.Number(strconv.Itoa(int(v.Enum())))
+	case protoreflect.BoolKind:
+		return v.Bool()
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.BytesKind:
+		return v.Bytes()
+	case protoreflect.FloatKind:
+		return floatToAny(v.Float(), 32)
+	case protoreflect.DoubleKind:
+		return floatToAny(v.Float(), 64)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return json.Number(strconv.FormatInt(v.Int(), 10))
+	default:
+		return json.Number(strconv.FormatUint(v.Uint(), 10))
+	}
+}
+
+// Timestamp、Duration、Any以及包装类型按照protojson的形式表示，与JSON数据的校验保持一致
+func wellKnownToAny(msg protoreflect.Message) any {
+	fields := msg.Descriptor().Fields()
+	switch name := msg.Descriptor().FullName(); {
+	case name == "google.protobuf.Timestamp":
+		seconds, nanos := msg.Get(fields.ByName("seconds")).Int(), msg.Get(fields.ByName("nanos")).Int()
+		return time.Unix(seconds, nanos).UTC().Format(time.RFC3339Nano)
+	case name == "google.protobuf.Duration":
+		seconds, nanos := msg.Get(fields.ByName("seconds")).Int(), msg.Get(fields.ByName("nanos")).Int()
+		return formatDuration(seconds, nanos)
+	case name == "google.protobuf.Any":
+		return map[string]any{"@type": msg.Get(fields.ByName("type_url")).String()}
+	case name == "google.protobuf.Struct", name == "google.protobuf.Value", name == "google.protobuf.ListValue",
+		name == "google.protobuf.FieldMask", name == "google.protobuf.Empty":
+		// 任意JSON值，使用protojson转换
+		if out, err := protojson.Marshal(msg.Interface()); err == nil {
+			var val any
+			decoder := json.NewDecoder(bytes.NewReader(out))
+			decoder.UseNumber()
+			if decoder.Decode(&val) == nil {
+				return val
+			}
+		}
+	case name.Parent() == "google.protobuf" && strings.HasSuffix(string(name), "Value") && fields.Len() == 1:
+		value := fields.ByName("value")
+		if value == nil {
+			break
+		}
+		return valueToAny(value, msg.Get(value))
+	}
+	return messageToMap(msg)
+}
+
+// 1.5s、-0.000000001s，与protojson的格式一致
+func formatDuration(seconds, nanos int64) string {
+	sign := ""
+	if seconds < 0 || nanos < 0 {
+		sign, seconds, nanos = "-", -seconds, -nanos
+	}
+	s := strconv.FormatInt(seconds, 10)
+	if nanos != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%09d", nanos), "0")
+	}
+	return sign + s + "s"
+}
+
+// 特殊浮点数按照protojson的约定使用字符串
+func floatToAny(f float64, bitSize int) any {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
@@ -2,11 +2,18 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 
 	pgs "github.com/lyft/protoc-gen-star/v2"
 	"github.com/spf13/afero"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"protocol-checker/check"
 )
 
 func main() {
@@ -18,24 +25,104 @@ func main() {
 	// ).RegisterPostProcessor(
 	// 	pgsgo.GoFmt(),
 	// ).Render()
-	args := os.Args
-	if len(args) != 2 {
-		fmt.Println("Usage: protoc-gen-check [pb_bin]")
-		return
+	var message, format, output, reportFormat string
+	var trace bool
+	opts := &check.Options{}
+	flag.StringVar(&message, "message", "", "待校验的根消息的全限定名，如 example.Protocol")
+	flag.StringVar(&format, "payload-format", "", "数据格式 json|binary|text，默认根据文件后缀推断")
+	flag.BoolVar(&opts.Lenient, "lenient", false, "宽松模式，允许数值、布尔值以字符串形式给出")
+	flag.StringVar(&opts.BytesEncoding, "bytes-encoding", check.BytesBase64, "JSON中字节串的编码 base64|hex|raw")
+	flag.StringVar(&opts.EnumFormat, "enum-format", "", "枚举值的写法 name|number，默认两者均可，仅对JSON数据生效")
+	flag.StringVar(&reportFormat, "format", check.FormatText, "校验结果的输出格式 text|json|junit|sarif，text输出到标准错误，其余输出到标准输出")
+	flag.BoolVar(&opts.Strict, "strict", false, "严格模式，数据中不属于消息字段的键视为错误")
+	flag.BoolVar(&opts.ApplyDefaults, "apply-defaults", false, "未设置的可选字段使用proto2中声明的默认值并校验")
+	flag.StringVar(&output, "output", "", "以JSON格式输出校验后的数据（包含默认值），- 表示标准输出")
+	flag.BoolVar(&trace, "trace", false, "将每个字段的校验过程输出到标准错误，便于排查规则")
+	flag.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintln(w, "Usage: protoc-gen-check [options] [pb_bin] [payload|-]")
+		fmt.Fprintln(w, "       protoc --check_out=message=<message>,payload=<payload>:<out_dir> ...")
+		fmt.Fprintln(w, "数据未通过校验或出错时以非零状态退出")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// 没有参数时作为protoc插件运行，请求从标准输入读取，数据通过插件参数 payload 指定
+	args := flag.Args()
+	plugin := len(args) == 0
+	if (!plugin && len(args) != 2) || !slices.Contains([]string{"", check.EnumName, check.EnumNumber}, opts.EnumFormat) ||
+		!slices.Contains([]string{check.BytesBase64, check.BytesHex, check.BytesRaw}, opts.BytesEncoding) ||
+		!slices.Contains([]string{check.FormatText, check.FormatJSON, check.FormatJUnit, check.FormatSARIF}, reportFormat) ||
+		((plugin || reportFormat != check.FormatText) && output == "-") {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if trace {
+		opts.Trace = func(t *check.FieldTrace) { fmt.Fprintln(os.Stderr, t) }
+	}
+
+	var req []byte
+	var err error
+	if plugin {
+		req, err = io.ReadAll(os.Stdin)
+	} else {
+		req, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		fatal("Error opening file:", err)
+	}
+
+	// 校验规则和二进制数据的解码都依赖请求中的描述符
+	var request pluginpb.CodeGeneratorRequest
+	if err := proto.Unmarshal(req, &request); err != nil {
+		fatal("Error loading descriptors:", err)
+	}
+	descs, err := check.NewDescriptors(&request)
+	if err != nil {
+		fatal("Error loading descriptors:", err)
+	}
 
+	// 待校验的数据，"-" 表示从标准输入读取。作为插件运行时标准输入是请求，只能从文件读取
+	var payloadPath string
+	if plugin {
+		payloadPath = pgs.ParseParameters(request.GetParameter()).Str("payload")
+		if payloadPath == "" || payloadPath == "-" {
+			fatal("Error reading payload: 作为protoc插件运行时需要通过参数 payload 指定数据文件")
+		}
+	} else {
+		payloadPath = args[1]
 	}
-	req, err := os.Open(args[1])
+	payload, err := check.ReadPayload(payloadPath, format)
 	if err != nil {
-		fmt.Println("Error opening file:", err)
-		return
+		fatal("Error reading payload:", err)
+	}
+
+	// 文本格式的结果输出到标准错误。作为插件运行时标准输出是返回给protoc的响应，所有格式都输出到标准错误
+	var report io.Writer = os.Stdout
+	if plugin || reportFormat == check.FormatText {
+		report = os.Stderr
 	}
 
 	fs := afero.NewMemMapFs()
-	res := &bytes.Buffer{}
+	var res io.Writer = os.Stdout
+	if !plugin {
+		res = &bytes.Buffer{} // 使用预先生成的请求时不输出响应
+	}
 
+	printer := ASTPrinter(message, payload, descs, opts, output, reportFormat, report)
 	pgs.Init(
-		pgs.ProtocInput(req),  // use the pre-generated request
-		pgs.ProtocOutput(res), // capture CodeGeneratorResponse
-		pgs.FileSystem(fs),    // capture any custom files written directly to disk
-	).RegisterModule(ASTPrinter()).Render()
+		pgs.ProtocInput(bytes.NewReader(req)), // 请求已经读取，重新提供给pgs
+		pgs.ProtocOutput(res),                 // 插件的响应
+		pgs.FileSystem(fs),                    // capture any custom files written directly to disk
+	).RegisterModule(printer).Render()
+
+	if !printer.Valid() {
+		os.Exit(1)
+	}
+}
+
+// 错误信息输出到标准错误，以非零状态退出
+func fatal(a ...any) {
+	fmt.Fprintln(os.Stderr, a...)
+	os.Exit(1)
 }
@@ -8,16 +8,31 @@ import (
 	"bytes"
 
 	pgs "github.com/lyft/protoc-gen-star/v2"
+
+	"protocol-checker/check"
 )
 
 type PrinterModule struct {
 	*pgs.ModuleBase
+	message string                 // 待校验的根消息，如 example.Protocol
+	payload *check.Payload         // 待校验的数据
+	descs   *check.Descriptors     // 请求中所有proto文件的描述符
+	opts    *check.Options         // 校验选项
+	output  string                 // 校验后数据的输出路径，为空时不输出
+	format  string                 // 校验结果的输出格式
+	report  io.Writer              // 校验结果的输出位置
+	result  check.ValidationResult // 校验结果，Execute之后有效
 }
 
-func ASTPrinter() *PrinterModule { return &PrinterModule{ModuleBase: &pgs.ModuleBase{}} }
+func ASTPrinter(message string, payload *check.Payload, descs *check.Descriptors, opts *check.Options, output string, format string, report io.Writer) *PrinterModule {
+	return &PrinterModule{ModuleBase: &pgs.ModuleBase{}, message: message, payload: payload, descs: descs, opts: opts, output: output, format: format, report: report}
+}
 
 func (p *PrinterModule) Name() string { return "printer" }
 
+// 数据是否通过校验
+func (p *PrinterModule) Valid() bool { return p.result.Valid() }
+
 func (p *PrinterModule) Execute(targets map[string]pgs.File, packages map[string]pgs.Package) []pgs.Artifact {
 	buf := &bytes.Buffer{}
 
@@ -25,6 +40,26 @@ func (p *PrinterModule) Execute(targets map[string]pgs.File, packages map[string
 		p.printFile(f, buf)
 	}
 
+	// 根消息未指定时使用插件参数 message
+	name := p.message
+	if name == "" {
+		name = p.Parameters().Str("message")
+	}
+	validator, err := p.descs.Compile(name, p.opts)
+	p.CheckErr(err, "unable to compile validator")
+
+	data, result, err := validator.Validate(p.payload)
+	p.CheckErr(err, "unable to decode payload")
+
+	p.result = result
+	report := check.Report{Message: validator.Message(), Payload: p.payload, Result: result}
+	p.CheckErr(check.WriteReport(p.report, p.format, report), "unable to write report")
+
+	// 输出校验后的数据，包含写入的默认值
+	if p.output != "" {
+		p.CheckErr(check.WriteNormalized(p.output, data), "unable to write normalized payload")
+	}
+
 	return p.Artifacts()
 }
 
@@ -108,13 +143,6 @@ func (v PrinterVisitor) VisitEnumValue(ev pgs.EnumValue) (pgs.Visitor, error) {
 }
 
 func (v PrinterVisitor) VisitField(f pgs.Field) (pgs.Visitor, error) {
-
-	// 对单个field进行校验
-	isValidate, msg := ParseField(f, ReadJsonData())
-
-	// 输出单个Field校验结果
-	OutputOneFieldValidateResult(f.Name().String(), isValidate, msg)
-
 	v.writeLeaf(f.Name().String())
 	return nil, nil
 }